@@ -0,0 +1,63 @@
+package gopensky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAircraftNotTracked is returned when OpenSky currently has no state for an aircraft, e.g. because it is on the
+// ground with its transponder off or out of coverage.
+var ErrAircraftNotTracked = errors.New("aircraft not tracked")
+
+// ErrUnknownRegistration is returned by a RegistrationResolver that has no ICAO24 address for a registration.
+var ErrUnknownRegistration = errors.New("unknown registration")
+
+// RegistrationResolver maps aircraft registrations (tail numbers) to ICAO24 addresses. The OpenSky API offers no such
+// mapping, so users provide their own source.
+type RegistrationResolver interface {
+	ResolveRegistration(registration string) (icao24 string, err error)
+}
+
+// Registrations is a RegistrationResolver backed by a map from registration to ICAO24 address.
+type Registrations map[string]string
+
+func (m Registrations) ResolveRegistration(registration string) (string, error) {
+	icao24, ok := m[registration]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRegistration, registration)
+	}
+	return icao24, nil
+}
+
+// GetAircraft returns the current state of the aircraft with the given ICAO24 address, or ErrAircraftNotTracked if
+// OpenSky has none.
+func GetAircraft(ctx context.Context, api Api, icao24 string) (*State, error) {
+	icao24 = strings.ToLower(icao24)
+	res, err := getContext(ctx, api, &Request{Icao24: []string{icao24}})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range res.States {
+		if s.Icao24 == icao24 {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrAircraftNotTracked, icao24)
+}
+
+// GetByRegistration returns the current state of the aircraft with the given registration, resolved to its ICAO24
+// address by resolver.
+func GetByRegistration(ctx context.Context, api Api, registration string, resolver RegistrationResolver) (*State, error) {
+	icao24, err := resolver.ResolveRegistration(registration)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := GetAircraft(ctx, api, icao24)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", registration, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,45 @@
+package gopensky
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGetByRegistration(t *testing.T) {
+	api := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		if len(req.Icao24) != 1 || req.Icao24[0] != "a0f1bb" {
+			t.Errorf("got icao24 filter %v, want [a0f1bb]", req.Icao24)
+		}
+		return &Response{States: []*State{{Icao24: "a0f1bb"}}}, nil
+	})
+	registrations := Registrations{"N12345": "A0F1BB"}
+
+	s, err := GetByRegistration(context.Background(), api, "N12345", registrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Icao24 != "a0f1bb" {
+		t.Errorf("got state of %s", s.Icao24)
+	}
+
+	if _, err := GetByRegistration(context.Background(), api, "N54321", registrations); !errors.Is(err, ErrUnknownRegistration) {
+		t.Errorf("got error %v, want %v", err, ErrUnknownRegistration)
+	}
+}
+
+func TestGetAircraftNotTracked(t *testing.T) {
+	api := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		return &Response{States: []*State{}}, nil
+	})
+	if _, err := GetAircraft(context.Background(), api, "a0f1bb"); !errors.Is(err, ErrAircraftNotTracked) {
+		t.Errorf("got error %v, want %v", err, ErrAircraftNotTracked)
+	}
+
+	failing := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, errors.New("GET not OK: 503 Service Unavailable")
+	})
+	if _, err := GetAircraft(context.Background(), failing, "a0f1bb"); err == nil || errors.Is(err, ErrAircraftNotTracked) {
+		t.Errorf("got error %v, want the request error", err)
+	}
+}
@@ -0,0 +1,54 @@
+package gopensky
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownAirport is returned by an AirportResolver that has no coordinates for an airport.
+var ErrUnknownAirport = errors.New("unknown airport")
+
+// AirportResolver looks up the position of an airport by its ICAO code. The package bundles no airport data, so users
+// provide their own source.
+type AirportResolver interface {
+	ResolveAirport(icao string) (Point, error)
+}
+
+// AirportPositions is an AirportResolver backed by a map from ICAO code to position.
+type AirportPositions map[string]Point
+
+func (m AirportPositions) ResolveAirport(icao string) (Point, error) {
+	p, ok := m[icao]
+	if !ok {
+		return Point{}, fmt.Errorf("%w: %s", ErrUnknownAirport, icao)
+	}
+	return p, nil
+}
+
+// CanonicalAirport returns the ICAO code of an airport in uppercase, or an error if code is not a 4-character ICAO
+// code. 3-letter IATA codes such as FRA are rejected with a hint, since OpenSky only knows airports by ICAO code.
+func CanonicalAirport(code string) (string, error) {
+	icao := strings.ToUpper(strings.TrimSpace(code))
+	if len(icao) == 3 {
+		return "", fmt.Errorf("airport code %q looks like an IATA code: use the 4-letter ICAO code, e.g. EDDF for FRA", code)
+	}
+	if len(icao) != 4 || strings.IndexFunc(icao, func(r rune) bool { return (r < 'A' || r > 'Z') && (r < '0' || r > '9') }) >= 0 {
+		return "", fmt.Errorf("airport code %q is not a 4-character ICAO code", code)
+	}
+	return icao, nil
+}
+
+// NewBboxForAirport returns a bounding box containing the circle of radiusKm around an airport resolved by resolver.
+// The ICAO code is canonicalized with CanonicalAirport before it is resolved.
+func NewBboxForAirport(resolver AirportResolver, icao string, radiusKm float64) (*Bbox, error) {
+	icao, err := CanonicalAirport(icao)
+	if err != nil {
+		return nil, err
+	}
+	p, err := resolver.ResolveAirport(icao)
+	if err != nil {
+		return nil, err
+	}
+	return NewBboxFromRadius(p.Latitude, p.Longitude, radiusKm), nil
+}
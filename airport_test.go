@@ -0,0 +1,42 @@
+package gopensky
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewBboxForAirport(t *testing.T) {
+	airports := AirportPositions{"EGLL": {Latitude: 51.4706, Longitude: -0.461941}}
+
+	b, err := NewBboxForAirport(airports, "egll", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Lamin > 51.4706 || b.Lamax < 51.4706 || b.Lomin > -0.461941 || b.Lomax < -0.461941 {
+		t.Errorf("got %+v, want a box around the airport", b)
+	}
+
+	if _, err := NewBboxForAirport(airports, "EDDF", 20); !errors.Is(err, ErrUnknownAirport) {
+		t.Errorf("got error %v, want %v", err, ErrUnknownAirport)
+	}
+}
+
+func TestCanonicalAirport(t *testing.T) {
+	tests := []struct {
+		code, want string
+	}{
+		{"EDDF", "EDDF"},
+		{" eddf", "EDDF"},
+		{"K1A1", "K1A1"},
+		{"FRA", ""},
+		{"EDD-", ""},
+		{"EDDFX", ""},
+	}
+
+	for _, test := range tests {
+		got, err := CanonicalAirport(test.code)
+		if got != test.want || (err == nil) != (test.want != "") {
+			t.Errorf("%q: got %q, %v, want %q", test.code, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package gopensky
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DecodeArchive reads a file of snapshots of /states/all, e.g. a downloaded archive, and calls fn with each snapshot in
+// turn, stopping at the first error fn returns. The snapshots may be concatenated or wrapped in a JSON array, and the
+// file may be gzip-compressed. Only one snapshot is held in memory at a time.
+func DecodeArchive(r io.Reader, fn func(*Response) error) error {
+	br, err := decompress(r)
+	if err != nil {
+		return err
+	}
+	first, err := peekNonSpace(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	for i := 0; ; i++ {
+		if first == '[' && !dec.More() {
+			_, err := dec.Token()
+			return err
+		}
+
+		var raw map[string]interface{}
+		if err := dec.Decode(&raw); err != nil {
+			if first != '[' && errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("snapshot %d: %w", i, err)
+		}
+		res, err := deserializeResponse(raw, false)
+		if err != nil {
+			return fmt.Errorf("snapshot %d: %w", i, err)
+		}
+		if err := fn(res); err != nil {
+			return err
+		}
+	}
+}
+
+// DecodeResponse reads a single stored response of /states/all, e.g. a recorded fixture, which may be
+// gzip-compressed. It is parsed strictly, like a response fetched with Get.
+func DecodeResponse(r io.Reader) (*Response, error) {
+	br, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := json.NewDecoder(br).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return deserializeResponse(raw, false)
+}
+
+// decompress returns a reader of r that is transparently decompressed if r is gzip-compressed.
+func decompress(r io.Reader) (*bufio.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	return bufio.NewReader(zr), nil
+}
+
+// peekNonSpace skips leading JSON whitespace and returns the next byte without consuming it.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
@@ -0,0 +1,62 @@
+package gopensky
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeArchive(t *testing.T) {
+	concatenated := emptyBody + "\n" + stateBody + "\n"
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write([]byte(concatenated))
+	zw.Close()
+
+	inputs := map[string]string{
+		"concatenated": concatenated,
+		"array":        " [" + emptyBody + ",\n" + stateBody + "]",
+		"gzip":         gzipped.String(),
+	}
+	for name, input := range inputs {
+		var times []int
+		err := DecodeArchive(strings.NewReader(input), func(res *Response) error {
+			times = append(times, res.Time)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if len(times) != 2 || times[0] != 1545462880 || times[1] != 1545462890 {
+			t.Errorf("%s: got snapshots at %v", name, times)
+		}
+	}
+
+	if err := DecodeArchive(strings.NewReader(emptyBody+stateBody[:40]), func(*Response) error { return nil }); err == nil {
+		t.Error("want an error for a truncated archive")
+	}
+}
+
+func TestDecodeResponseGzip(t *testing.T) {
+	var responses []*Response
+	for _, name := range []string{"sample.json", "sample.json.gz"} {
+		f, err := os.Open(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := DecodeResponse(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		responses = append(responses, res)
+	}
+
+	if len(responses[0].States) == 0 || !reflect.DeepEqual(responses[0], responses[1]) {
+		t.Error("want the gzipped fixture to decode like the plain one")
+	}
+}
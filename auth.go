@@ -0,0 +1,143 @@
+package gopensky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenURL is the endpoint API clients exchange their client credentials at for an access token.
+const TokenURL = "https://auth.opensky-network.org/auth/realms/opensky-network/protocol/openid-connect/token"
+
+// Tokens are refreshed this long before they expire, so that they don't run out while a request is in flight.
+const tokenExpiryMargin = 30 * time.Second
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "OPENSKY_CLIENT_ID"
+	EnvClientSecret = "OPENSKY_CLIENT_SECRET"
+	EnvUsername     = "OPENSKY_USERNAME"
+	EnvPassword     = "OPENSKY_PASSWORD"
+)
+
+// WithClientCredentials authenticates requests with the OAuth2 client credentials of an OpenSky API client. Access
+// tokens are obtained from TokenURL and cached until shortly before they expire.
+func WithClientCredentials(clientID, clientSecret string) Option {
+	return func(a *api) {
+		a.token = &tokenSource{clientID: clientID, clientSecret: clientSecret}
+	}
+}
+
+// WithBasicAuth authenticates requests with the username and password of an OpenSky account. OpenSky deprecated this
+// scheme in favour of client credentials and only accepts it for older accounts.
+func WithBasicAuth(username, password string) Option {
+	return func(a *api) {
+		a.username, a.password = username, password
+	}
+}
+
+// NewFromEnv returns an Api authenticated with the credentials found in the environment: the client credentials in
+// OPENSKY_CLIENT_ID and OPENSKY_CLIENT_SECRET or, failing that, the account in OPENSKY_USERNAME and OPENSKY_PASSWORD.
+// The Api is anonymous if neither is set. It is an error to set only one half of a pair.
+func NewFromEnv(httpClient *http.Client, opts ...Option) (Api, error) {
+	clientID, clientSecret, err := envPair(EnvClientID, EnvClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	username, password, err := envPair(EnvUsername, EnvPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case clientID != "":
+		opts = append([]Option{WithClientCredentials(clientID, clientSecret)}, opts...)
+	case username != "":
+		opts = append([]Option{WithBasicAuth(username, password)}, opts...)
+	}
+
+	return New(httpClient, opts...), nil
+}
+
+func envPair(first, second string) (string, string, error) {
+	a, b := os.Getenv(first), os.Getenv(second)
+	if (a == "") != (b == "") {
+		return "", "", fmt.Errorf("%s and %s must be set together", first, second)
+	}
+	return a, b, nil
+}
+
+func (a *api) authorize(ctx context.Context, req *http.Request) error {
+	switch {
+	case a.token != nil:
+		token, err := a.token.get(ctx, a.Http)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case a.username != "":
+		req.SetBasicAuth(a.username, a.password)
+	}
+	return nil
+}
+
+// tokenSource caches the access token of an API client.
+type tokenSource struct {
+	clientID     string
+	clientSecret string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (ts *tokenSource) get(ctx context.Context, httpClient *http.Client) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token != "" && time.Until(ts.expiry) > tokenExpiryMargin {
+		return ts.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {ts.clientID},
+		"client_secret": {ts.clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("POST %s not OK: %s", TokenURL, res.Status)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("token response has no access token")
+	}
+
+	ts.token = body.AccessToken
+	ts.expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	return ts.token, nil
+}
@@ -0,0 +1,58 @@
+package gopensky
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClientCredentials(t *testing.T) {
+	var tokenRequests int
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := stateBody
+		if req.URL.String() == TokenURL {
+			tokenRequests++
+			if err := req.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			if req.PostForm.Get("client_id") != "id" || req.PostForm.Get("client_secret") != "secret" {
+				t.Errorf("got token request form %v", req.PostForm)
+			}
+			body = `{"access_token":"token","expires_in":1800}`
+		} else if got := req.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("got Authorization header %q, want %q", got, "Bearer token")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})}
+
+	api := New(client, WithClientCredentials("id", "secret"))
+	for i := 0; i < 2; i++ {
+		if _, err := api.Get(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("got %d token requests, want the token to be cached", tokenRequests)
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv(EnvClientID, "")
+	t.Setenv(EnvClientSecret, "")
+	t.Setenv(EnvUsername, "user")
+	t.Setenv(EnvPassword, "pass")
+
+	client, err := NewFromEnv(&http.Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := client.(*api); a.username != "user" || a.password != "pass" || a.token != nil {
+		t.Errorf("got %+v, want basic auth", a)
+	}
+
+	t.Setenv(EnvClientID, "id")
+	if _, err := NewFromEnv(&http.Client{}); err == nil {
+		t.Error("expected an error for a client ID without a secret")
+	}
+}
@@ -0,0 +1,76 @@
+package gopensky
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Validate checks that the box has valid coordinates, does not cross the antimeridian, which OpenSky cannot query, and
+// keeps an area once its coordinates are rounded for the request.
+func (b *Bbox) Validate() error {
+	switch {
+	case b.Lamin < -90 || b.Lamax > 90:
+		return fmt.Errorf("bbox latitudes [%g, %g] outside [-90, 90]", b.Lamin, b.Lamax)
+	case b.Lomin < -180 || b.Lomax > 180:
+		return fmt.Errorf("bbox longitudes [%g, %g] outside [-180, 180]", b.Lomin, b.Lomax)
+	case b.Lamin > b.Lamax:
+		return fmt.Errorf("bbox lamin %g greater than lamax %g", b.Lamin, b.Lamax)
+	case b.Lomin > b.Lomax:
+		return fmt.Errorf("bbox lomin %g greater than lomax %g: boxes crossing the antimeridian must be split into "+
+			"one box on each side", b.Lomin, b.Lomax)
+	case formatDegrees(b.Lamin) == formatDegrees(b.Lamax) || formatDegrees(b.Lomin) == formatDegrees(b.Lomax):
+		return fmt.Errorf("bbox [%g, %g] x [%g, %g] has no area once rounded to the 4 decimals sent to OpenSky: use a "+
+			"larger box", b.Lamin, b.Lamax, b.Lomin, b.Lomax)
+	}
+	return nil
+}
+
+// Contains reports whether the point lies within the box, including its edges.
+func (b *Bbox) Contains(lat, lon float64) bool {
+	return lat >= b.Lamin && lat <= b.Lamax && lon >= b.Lomin && lon <= b.Lomax
+}
+
+// NewBboxFromRadius returns the smallest bounding box containing a circle of the given radius around a point. The box
+// is clamped to valid coordinates and spans all longitudes if the circle reaches a pole.
+func NewBboxFromRadius(lat, lon, radiusKm float64) *Bbox {
+	dLat := degrees(radiusKm / earthRadiusKm)
+	b := &Bbox{
+		Lamin: math.Max(lat-dLat, -90),
+		Lamax: math.Min(lat+dLat, 90),
+		Lomin: -180,
+		Lomax: 180,
+	}
+
+	if b.Lamin > -90 && b.Lamax < 90 {
+		dLon := dLat / math.Cos(radians(lat))
+		b.Lomin = math.Max(lon-dLon, -180)
+		b.Lomax = math.Min(lon+dLon, 180)
+	}
+
+	return b
+}
+
+// GetGroundTraffic returns the states of all aircraft on the ground within radiusKm of a point, e.g. an airport.
+//
+// The request is billed by the area of the surrounding bounding box: a radius below roughly 200 km costs a single API
+// credit at mid latitudes.
+func GetGroundTraffic(ctx context.Context, api Api, centerLat, centerLon float64, radiusKm float64) (*Response, error) {
+	res, err := getContext(ctx, api, &Request{Bbox: NewBboxFromRadius(centerLat, centerLon, radiusKm)})
+	if err != nil {
+		return nil, err
+	}
+
+	ground := *res
+	ground.States = res.OnGround()
+	return &ground, nil
+}
+
+// LocalSnapshotRadiusKm is the radius of the area GetLocalSnapshot queries.
+const LocalSnapshotRadiusKm = 100
+
+// GetLocalSnapshot returns the current states within LocalSnapshotRadiusKm of a point. It is the cheapest useful
+// query: the area costs a single API credit anywhere but in the polar regions, whereas querying all states costs four.
+func GetLocalSnapshot(ctx context.Context, api Api, lat, lon float64) (*Response, error) {
+	return getContext(ctx, api, &Request{Bbox: NewBboxFromRadius(lat, lon, LocalSnapshotRadiusKm)})
+}
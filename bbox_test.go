@@ -0,0 +1,132 @@
+package gopensky
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestNewBboxFromRadius(t *testing.T) {
+	// 111.2 km is roughly one degree of latitude.
+	b := NewBboxFromRadius(0, 10, 111.2)
+	if math.Abs(b.Lamin+1) > 0.01 || math.Abs(b.Lamax-1) > 0.01 {
+		t.Errorf("got latitude range [%f, %f], want about [-1, 1]", b.Lamin, b.Lamax)
+	}
+	if math.Abs(b.Lomin-9) > 0.01 || math.Abs(b.Lomax-11) > 0.01 {
+		t.Errorf("got longitude range [%f, %f], want about [9, 11]", b.Lomin, b.Lomax)
+	}
+
+	if b := NewBboxFromRadius(89.5, 10, 200); b.Lamax != 90 || b.Lomin != -180 || b.Lomax != 180 {
+		t.Errorf("got %+v, want a box spanning all longitudes up to the pole", b)
+	}
+}
+
+func TestBboxValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		bbox  Bbox
+		valid bool
+	}{
+		{"europe", Bbox{Lamin: 45.8389, Lomin: 5.9962, Lamax: 47.8229, Lomax: 10.5226}, true},
+		{"pacific", Bbox{Lamin: -47, Lomin: 165, Lamax: -15, Lomax: -175}, false},
+		{"fiji west", Bbox{Lamin: -21, Lomin: 176, Lamax: -15, Lomax: 180}, true},
+		{"fiji east", Bbox{Lamin: -21, Lomin: -180, Lamax: -15, Lomax: -178}, true},
+		{"inverted latitudes", Bbox{Lamin: 10, Lomin: 0, Lamax: -10, Lomax: 10}, false},
+		{"out of range", Bbox{Lamin: -95, Lomin: 0, Lamax: 10, Lomax: 10}, false},
+		{"rounds to a line", Bbox{Lamin: 47.45812, Lomin: 8.5491, Lamax: 47.45814, Lomax: 8.5611}, false},
+		{"small", Bbox{Lamin: 47.4581, Lomin: 8.5491, Lamax: 47.4582, Lomax: 8.5492}, true},
+	}
+
+	for _, test := range tests {
+		if err := test.bbox.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s: got error %v, want valid=%t", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestGetRejectsInvalidBbox(t *testing.T) {
+	var calls int
+	api := New(newSequenceClient(&calls, stateBody))
+
+	if _, err := api.Get(&Request{Bbox: &Bbox{Lamin: -47, Lomin: 165, Lamax: -15, Lomax: -175}}); err == nil {
+		t.Error("expected an error for a box crossing the antimeridian")
+	}
+	if calls != 0 {
+		t.Errorf("got %d requests, want none", calls)
+	}
+}
+
+func TestGetIcao24WithinBbox(t *testing.T) {
+	var query string
+	api := New(newQueryClient(&query, stateBody))
+
+	res, err := api.Get(&Request{Icao24: []string{"8076c4"}, Bbox: &Bbox{Lamin: 28, Lomin: 77, Lamax: 29, Lomax: 78}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "icao24=8076c4&lamax=29.0000&lamin=28.0000&lomax=78.0000&lomin=77.0000" || len(res.States) != 1 || res.CreditsUsed != 1 {
+		t.Errorf("got query %q, %d states and %d credits, want the box sent for 1 credit", query, len(res.States), res.CreditsUsed)
+	}
+
+	res, err = api.Get(&Request{Icao24: []string{"8076c4"}, Bbox: &Bbox{Lamin: 45, Lomin: 5, Lamax: 48, Lomax: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.States) != 0 {
+		t.Errorf("got %v, want the aircraft outside the box dropped", res.States)
+	}
+
+	res, err = api.Get(&Request{Icao24: []string{"8076c4"}, Bbox: &Bbox{Lamin: 0, Lomin: 60, Lamax: 40, Lomax: 90}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "icao24=8076c4" || len(res.States) != 1 || res.CreditsUsed != 4 {
+		t.Errorf("got query %q, %d states and %d credits, want a top tier box applied by the client only", query, len(res.States), res.CreditsUsed)
+	}
+}
+
+func TestGetGroundTraffic(t *testing.T) {
+	body := `{"time":1545462890,"states":[["8076c4","JAI824  ","India",1545462879,1545462879,77.4861,28.4392,1744.98,false,110.61,316.13,-3.58,null,1767.84,"2701",false,0],["800c0e","IGO6E11 ","India",1545462885,1545462885,77.1,28.56,null,true,0,90,null,null,null,"1000",false,0]]}`
+	var calls int
+	res, err := GetGroundTraffic(context.Background(), New(newSequenceClient(&calls, body)), 28.56, 77.1, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.States) != 1 || res.States[0].Icao24 != "800c0e" || res.Time != 1545462890 || res.CreditsUsed != 1 {
+		t.Errorf("got %d states, time %d and %d credits, want the aircraft on the ground for 1 credit", len(res.States), res.Time, res.CreditsUsed)
+	}
+}
+
+func TestGetLocalSnapshot(t *testing.T) {
+	var query string
+	res, err := GetLocalSnapshot(context.Background(), New(newQueryClient(&query, stateBody)), 28.5562, 77.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "lamax=29.4555&lamin=27.6569&lomax=78.1239&lomin=76.0761"; query != want {
+		t.Errorf("got query %q, want %q", query, want)
+	}
+	if len(res.States) != 1 || res.CreditsUsed != 1 {
+		t.Errorf("got %d states for %d credits, want 1 state for 1 credit", len(res.States), res.CreditsUsed)
+	}
+}
+
+// getOnlyApi is an Api without GetContext, like implementations written against the original interface.
+type getOnlyApi func(req *Request) (*Response, error)
+
+func (f getOnlyApi) Get(req *Request) (*Response, error) {
+	return f(req)
+}
+
+func TestGetGroundTrafficGetOnly(t *testing.T) {
+	api := getOnlyApi(func(req *Request) (*Response, error) {
+		return &Response{Time: 1545462890, States: []*State{{Icao24: "800c0e", OnGround: true}, {Icao24: "8076c4"}}}, nil
+	})
+	res, err := GetGroundTraffic(context.Background(), api, 28.56, 77.1, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.States) != 1 || res.States[0].Icao24 != "800c0e" {
+		t.Errorf("got %v, want the aircraft on the ground from Get", res.States)
+	}
+}
@@ -0,0 +1,71 @@
+package gopensky
+
+import (
+	"sync"
+	"time"
+)
+
+// BudgetManager keeps track of the API credits spent out of a daily allowance, such as UserDailyCredits, so that
+// pollers can skip a request rather than run out of credits. The spent credits are reset at midnight UTC, when
+// OpenSky resets them. It is safe for concurrent use.
+type BudgetManager struct {
+	daily int
+	now   func() time.Time
+
+	mu    sync.Mutex
+	spent int
+	day   time.Time
+}
+
+// NewBudgetManager returns a BudgetManager for the given number of credits per day, none of which are spent yet.
+func NewBudgetManager(daily int) *BudgetManager {
+	return &BudgetManager{
+		daily: daily,
+		now:   time.Now,
+	}
+}
+
+// Spend records that cost credits were spent, e.g. as estimated before a request.
+func (m *BudgetManager) Spend(cost int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reset()
+	m.spent += cost
+}
+
+// Record records the credits that res cost. If the remaining credits were reported with res, see WithCaptureHeaders,
+// they are trusted over the estimate.
+func (m *BudgetManager) Record(res *Response) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reset()
+	if remaining, ok := res.RemainingCredits(); ok {
+		m.spent = max(m.daily-remaining, 0)
+		return
+	}
+	m.spent += res.CreditsUsed
+}
+
+// Remaining returns the credits left for the day, which is never negative.
+func (m *BudgetManager) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reset()
+	return max(m.daily-m.spent, 0)
+}
+
+// CanSpend reports whether cost credits are left for the day.
+func (m *BudgetManager) CanSpend(cost int) bool {
+	return m.Remaining() >= cost
+}
+
+// reset forgets the spent credits once the day they were spent on is over. It must be called with mu held.
+func (m *BudgetManager) reset() {
+	if day := m.now().UTC().Truncate(24 * time.Hour); day.After(m.day) {
+		m.day = day
+		m.spent = 0
+	}
+}
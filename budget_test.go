@@ -0,0 +1,60 @@
+package gopensky
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBudgetManager(t *testing.T) {
+	now := time.Date(2018, 12, 22, 23, 0, 0, 0, time.UTC)
+	m := NewBudgetManager(10)
+	m.now = func() time.Time { return now }
+
+	m.Spend(4)
+	m.Record(&Response{CreditsUsed: 4})
+	if got := m.Remaining(); got != 2 {
+		t.Errorf("got %d credits remaining, want 2", got)
+	}
+	if m.CanSpend(4) || !m.CanSpend(2) {
+		t.Error("want 2 credits but not 4 to be spendable")
+	}
+
+	m.Spend(4)
+	if got := m.Remaining(); got != 0 {
+		t.Errorf("got %d credits remaining after overspending, want 0", got)
+	}
+
+	now = now.Add(time.Hour)
+	if got := m.Remaining(); got != 10 {
+		t.Errorf("got %d credits remaining after midnight, want 10", got)
+	}
+
+	m.Record(&Response{CreditsUsed: 1, Headers: http.Header{RemainingCreditsHeader: {"3"}}})
+	if got := m.Remaining(); got != 3 {
+		t.Errorf("got %d credits remaining, want the 3 reported", got)
+	}
+}
+
+func TestBudgetManagerConcurrent(t *testing.T) {
+	m := NewBudgetManager(UserDailyCredits)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Spend(1)
+				m.CanSpend(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The test may run across midnight UTC, after which fewer credits are spent.
+	if got := m.Remaining(); got < UserDailyCredits-800 {
+		t.Errorf("got %d credits remaining, want at least %d", got, UserDailyCredits-800)
+	}
+}
@@ -0,0 +1,91 @@
+package gopensky
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by an Api created by NewCircuitBroken while the circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type circuitBreaker struct {
+	inner     Api
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBroken wraps inner so that after threshold consecutive failed requests all requests fail fast with
+// ErrCircuitOpen for cooldown. After the cooldown a single trial request is let through: if it succeeds the circuit
+// closes again, otherwise it stays open for another cooldown. Requests cancelled by their context don't count as
+// failures, nor as successes. A threshold below 1 opens the circuit on the first failure, like 1.
+func NewCircuitBroken(inner Api, threshold int, cooldown time.Duration) Api {
+	return &circuitBreaker{
+		inner:     inner,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+func (b *circuitBreaker) Get(req *Request) (*Response, error) {
+	return b.GetContext(context.Background(), req)
+}
+
+func (b *circuitBreaker) GetContext(ctx context.Context, req *Request) (*Response, error) {
+	trial, err := b.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := getContext(ctx, b.inner, req)
+	b.release(trial, err, ctx.Err() != nil)
+	return res, err
+}
+
+// acquire lets a request through unless the circuit is open, reporting whether it is the trial request.
+func (b *circuitBreaker) acquire() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return false, nil
+	}
+	if b.trial || b.now().Before(b.openedAt.Add(b.cooldown)) {
+		return false, ErrCircuitOpen
+	}
+	b.trial = true
+	return true, nil
+}
+
+// release records the outcome of a request. Only the trial request ends the trial, so that requests let through
+// before the circuit opened don't let another trial start while one is in flight. A request that failed because its
+// context was cancelled is neither a success nor a failure: it leaves the circuit as it was, and a cancelled trial
+// lets the next request be the trial.
+func (b *circuitBreaker) release(trial bool, err error, cancelled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if trial {
+		b.trial = false
+	}
+	switch {
+	case err == nil:
+		b.failures = 0
+		return
+	case cancelled:
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
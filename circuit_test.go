@@ -0,0 +1,150 @@
+package gopensky
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeApi answers requests with a function.
+type fakeApi func(ctx context.Context, req *Request) (*Response, error)
+
+func (f fakeApi) Get(req *Request) (*Response, error) {
+	return f(context.Background(), req)
+}
+
+func (f fakeApi) GetContext(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls int
+	failing := true
+	inner := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		calls++
+		if failing {
+			return nil, errors.New("service unavailable")
+		}
+		return &Response{}, nil
+	})
+
+	now := time.Unix(1545462880, 0)
+	api := NewCircuitBroken(inner, 2, time.Minute)
+	api.(*circuitBreaker).now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.Get(nil); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d: got error %v, want the inner error", i, err)
+		}
+	}
+	if _, err := api.Get(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got error %v, want %v", err, ErrCircuitOpen)
+	}
+	if calls != 2 {
+		t.Errorf("got %d calls to the inner Api, want 2", calls)
+	}
+
+	// A failed trial keeps the circuit open for another cooldown.
+	now = now.Add(time.Minute)
+	if _, err := api.Get(nil); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("want a trial request after the cooldown")
+	}
+	if _, err := api.Get(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got error %v, want %v after a failed trial", err, ErrCircuitOpen)
+	}
+
+	now = now.Add(time.Minute)
+	failing = false
+	for i := 0; i < 2; i++ {
+		if _, err := api.Get(nil); err != nil {
+			t.Fatalf("request %d: got error %v, want the circuit closed", i, err)
+		}
+	}
+}
+
+func TestCircuitBreakerTrialInFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	inner := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		if req != nil {
+			started <- struct{}{}
+			<-release
+		}
+		return nil, errors.New("service unavailable")
+	})
+
+	now := time.Unix(1545462880, 0)
+	api := NewCircuitBroken(inner, 0, time.Minute)
+	api.(*circuitBreaker).now = func() time.Time { return now }
+
+	// A slow request is let through while the circuit is closed, and the circuit opens on the first failure.
+	slow := make(chan error)
+	go func() {
+		_, err := api.Get(&Request{})
+		slow <- err
+	}()
+	<-started
+	if _, err := api.Get(nil); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("want the request let through before the first failure")
+	}
+
+	now = now.Add(time.Minute)
+	trial := make(chan error)
+	go func() {
+		_, err := api.Get(&Request{})
+		trial <- err
+	}()
+	<-started
+
+	// The slow request failing must not end the trial that is still in flight, even once it is over the cooldown.
+	release <- struct{}{}
+	<-slow
+	now = now.Add(time.Minute)
+	if _, err := api.Get(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("got error %v during the trial, want %v", err, ErrCircuitOpen)
+	}
+	release <- struct{}{}
+	<-trial
+}
+
+func TestCircuitBreakerCancelled(t *testing.T) {
+	var calls int
+	inner := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		calls++
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("service unavailable")
+	})
+
+	now := time.Unix(1545462880, 0)
+	api := NewCircuitBroken(inner, 2, time.Minute)
+	api.(*circuitBreaker).now = func() time.Time { return now }
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A cancelled request between two failures neither resets nor extends the streak.
+	api.Get(nil)
+	if _, err := getContext(cancelled, api, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+	api.Get(nil)
+	if _, err := api.Get(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got error %v after two failures, want %v", err, ErrCircuitOpen)
+	}
+
+	// A cancelled trial leaves the circuit open, and the next request is the trial instead.
+	now = now.Add(time.Minute)
+	if _, err := getContext(cancelled, api, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v for the trial, want %v", err, context.Canceled)
+	}
+	if _, err := api.Get(nil); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("want another trial after a cancelled one")
+	}
+	if _, err := api.Get(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("got error %v after the failed trial, want %v", err, ErrCircuitOpen)
+	}
+	if calls != 5 {
+		t.Errorf("got %d calls to the inner Api, want 5", calls)
+	}
+}
@@ -0,0 +1,71 @@
+package gopensky
+
+// StateColumns holds the states of a response as parallel slices, one per field, for numeric processing over many
+// states or conversion into a column-oriented format. Element i of each slice belongs to the i-th state.
+type StateColumns struct {
+	Icao24         []string
+	Callsign       []string
+	OriginCountry  []string
+	TimePosition   []int
+	LastContact    []int
+	Longitude      []float64
+	Latitude       []float64
+	BaroAltitude   []float64
+	OnGround       []bool
+	Velocity       []float64
+	TrueTrack      []float64
+	VerticalRate   []float64
+	Sensors        [][]int
+	GeoAltitude    []float64
+	Squawk         []string
+	Spi            []bool
+	PositionSource []PositionSource
+	Category       []Category
+}
+
+// Columns returns the states of the response in a columnar layout.
+func (r *Response) Columns() *StateColumns {
+	n := len(r.States)
+	c := &StateColumns{
+		Icao24:         make([]string, n),
+		Callsign:       make([]string, n),
+		OriginCountry:  make([]string, n),
+		TimePosition:   make([]int, n),
+		LastContact:    make([]int, n),
+		Longitude:      make([]float64, n),
+		Latitude:       make([]float64, n),
+		BaroAltitude:   make([]float64, n),
+		OnGround:       make([]bool, n),
+		Velocity:       make([]float64, n),
+		TrueTrack:      make([]float64, n),
+		VerticalRate:   make([]float64, n),
+		Sensors:        make([][]int, n),
+		GeoAltitude:    make([]float64, n),
+		Squawk:         make([]string, n),
+		Spi:            make([]bool, n),
+		PositionSource: make([]PositionSource, n),
+		Category:       make([]Category, n),
+	}
+
+	for i, s := range r.States {
+		c.Icao24[i] = s.Icao24
+		c.Callsign[i] = s.Callsign
+		c.OriginCountry[i] = s.OriginCountry
+		c.TimePosition[i] = s.TimePosition
+		c.LastContact[i] = s.LastContact
+		c.Longitude[i] = s.Longitude
+		c.Latitude[i] = s.Latitude
+		c.BaroAltitude[i] = s.BaroAltitude
+		c.OnGround[i] = s.OnGround
+		c.Velocity[i] = s.Velocity
+		c.TrueTrack[i] = s.TrueTrack
+		c.VerticalRate[i] = s.VerticalRate
+		c.Sensors[i] = s.Sensors
+		c.GeoAltitude[i] = s.GeoAltitude
+		c.Squawk[i] = s.Squawk
+		c.Spi[i] = s.Spi
+		c.PositionSource[i] = s.PositionSource
+		c.Category[i] = s.Category
+	}
+	return c
+}
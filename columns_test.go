@@ -0,0 +1,12 @@
+package gopensky
+
+import "testing"
+
+func TestColumns(t *testing.T) {
+	res := &Response{States: []*State{{Icao24: "8076c4", Latitude: 28.4392, OnGround: true}, {Icao24: "aa56da", Category: CategoryHeavy}}}
+
+	c := res.Columns()
+	if len(c.Icao24) != 2 || c.Icao24[1] != "aa56da" || c.Latitude[0] != 28.4392 || !c.OnGround[0] || c.Category[1] != CategoryHeavy {
+		t.Errorf("got %+v", c)
+	}
+}
@@ -0,0 +1,22 @@
+package gopensky
+
+import "testing"
+
+func TestCountryForICAO24(t *testing.T) {
+	res, err := deserializeResponse(loadTestdata(t, "sample.json"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range res.States {
+		if got, ok := CountryForICAO24(s.Icao24); !ok || got != s.OriginCountry {
+			t.Errorf("%s: got %q, want %q", s.Icao24, got, s.OriginCountry)
+		}
+	}
+
+	for _, icao24 := range []string{"", "abc", "zzzzzz", "f00000", "3C6444x"} {
+		if got, ok := CountryForICAO24(icao24); ok {
+			t.Errorf("%q: got %q, want no country", icao24, got)
+		}
+	}
+}
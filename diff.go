@@ -0,0 +1,62 @@
+package gopensky
+
+import "context"
+
+// ResponseDiff describes how the states changed between two responses.
+type ResponseDiff struct {
+	// Time of the newer response.
+	Time int
+	// States of aircraft that were not in the older response.
+	Added []*State
+	// States of aircraft that OpenSky received new messages from since the older response.
+	Updated []*State
+	// Last known states of aircraft that are missing from the newer response.
+	Removed []*State
+}
+
+// Empty reports whether nothing changed.
+func (d *ResponseDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+// Diff compares the states of two responses by ICAO24 address. A nil prev is treated as an empty response.
+func Diff(prev, next *Response) *ResponseDiff {
+	d := &ResponseDiff{Time: next.Time}
+
+	old := make(map[string]*State)
+	if prev != nil {
+		for _, s := range prev.States {
+			old[s.Icao24] = s
+		}
+	}
+
+	for _, s := range next.States {
+		p, ok := old[s.Icao24]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, s)
+		case s.LastContact != p.LastContact:
+			d.Updated = append(d.Updated, s)
+		}
+		delete(old, s.Icao24)
+	}
+
+	if prev != nil {
+		for _, s := range prev.States {
+			if _, ok := old[s.Icao24]; ok {
+				d.Removed = append(d.Removed, s)
+			}
+		}
+	}
+	return d
+}
+
+// GetChangesSince fetches the current states for req and returns how they changed since prev, along with the new
+// snapshot to pass as prev next time. OpenSky has no delta API, so the full snapshot is still downloaded.
+func GetChangesSince(ctx context.Context, api Api, req *Request, prev *Response) (*ResponseDiff, *Response, error) {
+	res, err := getContext(ctx, api, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Diff(prev, res), res, nil
+}
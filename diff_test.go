@@ -0,0 +1,40 @@
+package gopensky
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	prev := &Response{Time: 1545462880, States: []*State{{Icao24: "8076c4", LastContact: 1545462879}, {Icao24: "aa56da", LastContact: 1545462646}, {Icao24: "7c6b2f", LastContact: 1545462879}}}
+	next := &Response{Time: 1545462890, States: []*State{{Icao24: "8076c4", LastContact: 1545462889}, {Icao24: "aa56da", LastContact: 1545462646}, {Icao24: "4b1804", LastContact: 1545462890}}}
+
+	d := Diff(prev, next)
+	if d.Time != 1545462890 || len(d.Added) != 1 || d.Added[0].Icao24 != "4b1804" {
+		t.Errorf("got added %v", d.Added)
+	}
+	if len(d.Updated) != 1 || d.Updated[0].Icao24 != "8076c4" {
+		t.Errorf("got updated %v", d.Updated)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Icao24 != "7c6b2f" {
+		t.Errorf("got removed %v", d.Removed)
+	}
+
+	if d := Diff(nil, next); len(d.Added) != 3 || !Diff(next, next).Empty() {
+		t.Errorf("got %+v from an empty response", d)
+	}
+}
+
+func TestGetChangesSince(t *testing.T) {
+	var calls int
+	api := New(newSequenceClient(&calls, stateBody))
+	prev := &Response{Time: 1545462880, States: []*State{{Icao24: "aa56da"}}}
+
+	diff, res, err := GetChangesSince(context.Background(), api, nil, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.States) != 1 || len(diff.Added) != 1 || diff.Added[0] != res.States[0] || len(diff.Removed) != 1 {
+		t.Errorf("got diff %+v of %+v", diff, res)
+	}
+}
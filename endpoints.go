@@ -0,0 +1,27 @@
+package gopensky
+
+import "time"
+
+// EndpointInfo describes an OpenSky endpoint supported by this package.
+type EndpointInfo struct {
+	// Path relative to Root, e.g. "/states/all".
+	Path string
+	// Query parameters the endpoint requires and those it optionally accepts.
+	RequiredParams []string
+	OptionalParams []string
+	// How far in the past data can be requested, or zero if only current data is served.
+	MaxHistory time.Duration
+	// Whether the endpoint can only be used with credentials.
+	RequiresAuth bool
+}
+
+// Endpoints returns the OpenSky endpoints supported by this package, for tooling that builds requests dynamically.
+func Endpoints() []EndpointInfo {
+	return []EndpointInfo{
+		{
+			Path:           "/states/all",
+			OptionalParams: []string{"time", "icao24", "lamin", "lomin", "lamax", "lomax", "extended"},
+			MaxHistory:     MaxStatesHistory,
+		},
+	}
+}
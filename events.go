@@ -0,0 +1,48 @@
+package gopensky
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// StateEvent is a position report of a vehicle as an immutable event.
+type StateEvent struct {
+	// Stable ID derived from the ICAO24 address and the position timestamp. A position reported in several
+	// consecutive responses maps to the same ID.
+	ID string
+	// Time of the position report.
+	Timestamp time.Time
+	State     *State
+}
+
+// ToEvents converts the states that carry a position into events, one per ICAO24 address and position timestamp.
+// Since the IDs are stable across responses, an event store deduplicating by ID receives every position once no matter
+// how often it is polled.
+func (r *Response) ToEvents() []StateEvent {
+	events := make([]StateEvent, 0, len(r.States))
+	seen := make(map[string]bool, len(r.States))
+	for _, s := range r.States {
+		if !s.HasPosition() {
+			continue
+		}
+
+		id := stateEventID(s)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		events = append(events, StateEvent{
+			ID:        id,
+			Timestamp: time.Unix(int64(s.TimePosition), 0),
+			State:     s,
+		})
+	}
+	return events
+}
+
+func stateEventID(s *State) string {
+	sum := sha256.Sum256([]byte(s.Icao24 + "/" + strconv.Itoa(s.TimePosition)))
+	return hex.EncodeToString(sum[:16])
+}
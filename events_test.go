@@ -0,0 +1,22 @@
+package gopensky
+
+import "testing"
+
+func TestToEvents(t *testing.T) {
+	first := &Response{States: []*State{
+		{Icao24: "8076c4", TimePosition: 1545462879},
+		{Icao24: "8076c4", TimePosition: 1545462879},
+		{Icao24: "aa56da", TimePosition: NoTimePosition},
+	}}
+	second := &Response{States: []*State{{Icao24: "8076c4", TimePosition: 1545462879}, {Icao24: "8076c4", TimePosition: 1545462889}}}
+
+	events := first.ToEvents()
+	if len(events) != 1 || events[0].Timestamp.Unix() != 1545462879 {
+		t.Fatalf("got %+v, want a single event for 8076c4", events)
+	}
+
+	next := second.ToEvents()
+	if len(next) != 2 || next[0].ID != events[0].ID || next[1].ID == events[0].ID {
+		t.Errorf("got IDs %s and %s after %s, want the repeated position to keep its ID", next[0].ID, next[1].ID, events[0].ID)
+	}
+}
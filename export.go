@@ -0,0 +1,256 @@
+package gopensky
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// ExportOption configures how states are exported.
+type ExportOption func(*exportConfig)
+
+type exportConfig struct {
+	precision           int
+	coordinatePrecision int
+}
+
+// Decimal places of exported values unless configured otherwise. Coordinates are not rounded by default.
+const (
+	defaultExportPrecision     = 1
+	defaultCoordinatePrecision = -1
+)
+
+// WithExportPrecision rounds exported altitudes, velocities and vertical rates to the given number of decimal places.
+func WithExportPrecision(decimals int) ExportOption {
+	return func(c *exportConfig) {
+		c.precision = decimals
+	}
+}
+
+// WithCoordinatePrecision rounds exported latitudes and longitudes to the given number of decimal places. A negative
+// value keeps them as received.
+func WithCoordinatePrecision(decimals int) ExportOption {
+	return func(c *exportConfig) {
+		c.coordinatePrecision = decimals
+	}
+}
+
+func newExportConfig(opts []ExportOption) *exportConfig {
+	c := &exportConfig{
+		precision:           defaultExportPrecision,
+		coordinatePrecision: defaultCoordinatePrecision,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// round rounds f to the given number of decimal places, or returns it unchanged if decimals is negative.
+func round(f float64, decimals int) float64 {
+	if decimals < 0 {
+		return f
+	}
+	scale := math.Pow10(decimals)
+	return math.Round(f*scale) / scale
+}
+
+// stateRecord is the self-contained object form of a state used by the exporters.
+type stateRecord struct {
+	Time           int            `json:"time"`
+	Icao24         string         `json:"icao24"`
+	Callsign       string         `json:"callsign,omitempty"`
+	OriginCountry  string         `json:"origin_country"`
+	TimePosition   *int           `json:"time_position,omitempty"`
+	LastContact    int            `json:"last_contact"`
+	Longitude      *float64       `json:"longitude,omitempty"`
+	Latitude       *float64       `json:"latitude,omitempty"`
+	BaroAltitude   float64        `json:"baro_altitude"`
+	OnGround       bool           `json:"on_ground"`
+	Velocity       float64        `json:"velocity"`
+	TrueTrack      float64        `json:"true_track"`
+	VerticalRate   float64        `json:"vertical_rate"`
+	Sensors        []int          `json:"sensors,omitempty"`
+	GeoAltitude    float64        `json:"geo_altitude"`
+	Squawk         string         `json:"squawk,omitempty"`
+	Spi            bool           `json:"spi"`
+	PositionSource PositionSource `json:"position_source"`
+	Category       Category       `json:"category,omitempty"`
+}
+
+func newStateRecord(time int, s *State, c *exportConfig) *stateRecord {
+	rec := &stateRecord{
+		Time:           time,
+		Icao24:         s.Icao24,
+		Callsign:       s.Callsign,
+		OriginCountry:  s.OriginCountry,
+		LastContact:    s.LastContact,
+		BaroAltitude:   round(s.BaroAltitude, c.precision),
+		OnGround:       s.OnGround,
+		Velocity:       round(s.Velocity, c.precision),
+		TrueTrack:      s.TrueTrack,
+		VerticalRate:   round(s.VerticalRate, c.precision),
+		Sensors:        s.Sensors,
+		GeoAltitude:    round(s.GeoAltitude, c.precision),
+		Squawk:         s.Squawk,
+		Spi:            s.Spi,
+		PositionSource: s.PositionSource,
+		Category:       s.Category,
+	}
+
+	if s.HasPosition() {
+		timePosition := s.TimePosition
+		lon, lat := round(s.Longitude, c.coordinatePrecision), round(s.Latitude, c.coordinatePrecision)
+		rec.TimePosition, rec.Longitude, rec.Latitude = &timePosition, &lon, &lat
+	}
+
+	return rec
+}
+
+// stateObject is the object form of a state documented by OpenSky, with fields in the order of the state vector.
+type stateObject struct {
+	Icao24         string         `json:"icao24"`
+	Callsign       *string        `json:"callsign"`
+	OriginCountry  string         `json:"origin_country"`
+	TimePosition   *int           `json:"time_position"`
+	LastContact    int            `json:"last_contact"`
+	Longitude      *float64       `json:"longitude"`
+	Latitude       *float64       `json:"latitude"`
+	BaroAltitude   float64        `json:"baro_altitude"`
+	OnGround       bool           `json:"on_ground"`
+	Velocity       float64        `json:"velocity"`
+	TrueTrack      float64        `json:"true_track"`
+	VerticalRate   float64        `json:"vertical_rate"`
+	Sensors        []int          `json:"sensors"`
+	GeoAltitude    float64        `json:"geo_altitude"`
+	Squawk         *string        `json:"squawk"`
+	Spi            bool           `json:"spi"`
+	PositionSource PositionSource `json:"position_source"`
+	Category       Category       `json:"category"`
+}
+
+// MarshalJSON encodes the state as a JSON object with the field names documented by OpenSky. Values that OpenSky
+// reported as null are encoded as null where they can be told apart from a zero value. UnmarshalJSON decodes it back.
+func (s State) MarshalJSON() ([]byte, error) {
+	obj := stateObject{
+		Icao24:         s.Icao24,
+		OriginCountry:  s.OriginCountry,
+		LastContact:    s.LastContact,
+		BaroAltitude:   s.BaroAltitude,
+		OnGround:       s.OnGround,
+		Velocity:       s.Velocity,
+		TrueTrack:      s.TrueTrack,
+		VerticalRate:   s.VerticalRate,
+		GeoAltitude:    s.GeoAltitude,
+		Spi:            s.Spi,
+		PositionSource: s.PositionSource,
+		Category:       s.Category,
+	}
+	if s.Callsign != "" {
+		obj.Callsign = &s.Callsign
+	}
+	if s.HasPosition() {
+		obj.TimePosition, obj.Longitude, obj.Latitude = &s.TimePosition, &s.Longitude, &s.Latitude
+	}
+	if len(s.Sensors) > 0 {
+		obj.Sensors = s.Sensors
+	}
+	if s.Squawk != "" {
+		obj.Squawk = &s.Squawk
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a state from the object form written by MarshalJSON, like UnmarshalStateObject, so that states
+// round-trip through encoding/json.
+func (s *State) UnmarshalJSON(data []byte) error {
+	decoded, err := UnmarshalStateObject(data)
+	if err != nil {
+		return err
+	}
+	*s = *decoded
+	return nil
+}
+
+// UnmarshalStateObject decodes a state from the object form written by MarshalJSON and documented by OpenSky. The
+// object is mapped onto a state vector and parsed like one, so both forms decode into identical states. Missing fields
+// count as null, and category may be left out as in responses that are not extended.
+func UnmarshalStateObject(data []byte) (*State, error) {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	n := len(stateFields)
+	if _, ok := obj[stateFields[n-1]]; !ok {
+		n--
+	}
+	vec := make([]interface{}, n)
+	for i := range vec {
+		vec[i] = obj[stateFields[i]]
+	}
+
+	s, err := deserializeState(vec)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.ParseWarnings) > 0 {
+		return nil, fmt.Errorf("malformed state object: %s", strings.Join(s.ParseWarnings, "; "))
+	}
+	return s, nil
+}
+
+// WriteNDJSON writes each state as a JSON object on its own line, in the format expected by most log pipelines and
+// analytics sinks. Every record carries the response time so that it is self-contained. Fields that OpenSky reported
+// as null are omitted where they can be told apart from a zero value.
+func (r *Response) WriteNDJSON(w io.Writer, opts ...ExportOption) error {
+	c := newExportConfig(opts)
+	enc := json.NewEncoder(w)
+	for _, s := range r.States {
+		if err := enc.Encode(newStateRecord(r.Time, s, c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Format is a format states can be exported in.
+type Format int
+
+const (
+	// JSON object per state and line, as written by WriteNDJSON.
+	FormatNDJSON Format = iota
+)
+
+// write exports the states of r to w in format f.
+func (f Format) write(w io.Writer, r *Response) error {
+	switch f {
+	case FormatNDJSON:
+		return r.WriteNDJSON(w)
+	default:
+		return fmt.Errorf("unsupported export format %d", f)
+	}
+}
+
+func init() {
+	// Extra elements of state vectors hold values decoded from JSON.
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+// SaveResponse writes the response to w in the compact gob encoding, e.g. to keep a cache on disk.
+func SaveResponse(w io.Writer, r *Response) error {
+	return gob.NewEncoder(w).Encode(r)
+}
+
+// LoadResponse reads a response written by SaveResponse. As with any gob, empty slices are read back as nil.
+func LoadResponse(r io.Reader) (*Response, error) {
+	var res Response
+	if err := gob.NewDecoder(r).Decode(&res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,123 @@
+package gopensky
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	res := &Response{
+		Time: 1545462880,
+		States: []*State{
+			{Icao24: "8076c4", Callsign: "JAI824  ", OriginCountry: "India", TimePosition: 1545462879, LastContact: 1545462879, Longitude: 77.4861, Latitude: 28.4392},
+			{Icao24: "aa56da", OriginCountry: "United States", TimePosition: NoTimePosition, LastContact: 1545462646},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := res.WriteNDJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"time":1545462880,"icao24":"8076c4","callsign":"JAI824  ","origin_country":"India","time_position":1545462879,"last_contact":1545462879,"longitude":77.4861,"latitude":28.4392,"baro_altitude":0,"on_ground":false,"velocity":0,"true_track":0,"vertical_rate":0,"geo_altitude":0,"spi":false,"position_source":0}
+{"time":1545462880,"icao24":"aa56da","origin_country":"United States","last_contact":1545462646,"baro_altitude":0,"on_ground":false,"velocity":0,"true_track":0,"vertical_rate":0,"geo_altitude":0,"spi":false,"position_source":0}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSaveLoadResponse(t *testing.T) {
+	res, err := deserializeResponse(loadTestdata(t, "sample.json"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := SaveResponse(&buf, res); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadResponse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range res.States {
+		if len(s.Sensors) == 0 {
+			s.Sensors = nil
+		}
+	}
+	if !reflect.DeepEqual(loaded, res) {
+		t.Error("loaded response differs from the saved one")
+	}
+}
+
+func TestWriteNDJSONPrecision(t *testing.T) {
+	res := &Response{States: []*State{{TimePosition: 1545462879, Longitude: 77.48612345, Latitude: 28.43921234, BaroAltitude: 11277.6000000001, Velocity: 110.6149}}}
+
+	tests := []struct {
+		opts []ExportOption
+		want string
+	}{
+		{nil, `"longitude":77.48612345,"latitude":28.43921234,"baro_altitude":11277.6,"on_ground":false,"velocity":110.6,`},
+		{[]ExportOption{WithExportPrecision(0), WithCoordinatePrecision(4)}, `"longitude":77.4861,"latitude":28.4392,"baro_altitude":11278,"on_ground":false,"velocity":111,`},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := res.WriteNDJSON(&buf, test.opts...); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte(test.want)) {
+			t.Errorf("got %s, want it to contain %s", buf.String(), test.want)
+		}
+	}
+}
+
+func TestStateMarshalJSON(t *testing.T) {
+	s := &State{Icao24: "aa56da", Callsign: "UAL482  ", OriginCountry: "United States", TimePosition: NoTimePosition, LastContact: 1545462646, BaroAltitude: 868.68, Velocity: 23.66, TrueTrack: 264, Sensors: []int{}, GeoAltitude: 883.92, Squawk: "3777"}
+
+	got, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"icao24":"aa56da","callsign":"UAL482  ","origin_country":"United States","time_position":null,"last_contact":1545462646,"longitude":null,"latitude":null,"baro_altitude":868.68,"on_ground":false,"velocity":23.66,"true_track":264,"vertical_rate":0,"sensors":null,"geo_altitude":883.92,"squawk":"3777","spi":false,"position_source":0,"category":0}`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnmarshalStateObject(t *testing.T) {
+	res, err := deserializeResponse(loadTestdata(t, "sample.json"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range res.States[:50] {
+		data, err := json.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := UnmarshalStateObject(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+
+		var s State
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&s, want) {
+			t.Errorf("got %+v with json.Unmarshal, want %+v", s, want)
+		}
+	}
+
+	if _, err := UnmarshalStateObject([]byte(`{"icao24":"aa56da"}`)); err == nil {
+		t.Error("want an error for a state object without required fields")
+	}
+}
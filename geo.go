@@ -0,0 +1,175 @@
+package gopensky
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Mean earth radius in kilometers.
+const earthRadiusKm = 6371.0088
+
+// ErrNoPosition is returned by computations that need the position of a state without one.
+var ErrNoPosition = errors.New("state has no position")
+
+// ErrNoVelocity is returned by computations that need a vehicle to be moving.
+var ErrNoVelocity = errors.New("state has no velocity")
+
+// ErrHeadingAway is returned by ETATo for a vehicle whose track leads away from the destination.
+var ErrHeadingAway = errors.New("heading away from destination")
+
+// Point is a WGS-84 position in decimal degrees.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// distanceKm returns the great-circle distance between two points.
+func distanceKm(p, q Point) float64 {
+	lat1, lat2 := radians(p.Latitude), radians(q.Latitude)
+	dLat, dLon := lat2-lat1, radians(q.Longitude-p.Longitude)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(math.Sqrt(a), 1))
+}
+
+// initialBearing returns the bearing in degrees clockwise from north at which the great circle from p to q starts.
+func initialBearing(p, q Point) float64 {
+	lat1, lat2 := radians(p.Latitude), radians(q.Latitude)
+	dLon := radians(q.Longitude - p.Longitude)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// destination returns the point reached by travelling distanceKm along a great circle from p with the given initial
+// bearing in degrees clockwise from north.
+func destination(p Point, bearing, distanceKm float64) Point {
+	lat1, lon1 := radians(p.Latitude), radians(p.Longitude)
+	brng := radians(bearing)
+	d := distanceKm / earthRadiusKm
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(brng))
+	lon2 := lon1 + math.Atan2(math.Sin(brng)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+
+	// Normalize the longitude to [-180, 180).
+	return Point{
+		Latitude:  degrees(lat2),
+		Longitude: math.Mod(degrees(lon2)+540, 360) - 180,
+	}
+}
+
+// ClosestApproach predicts when and how close two vehicles get to each other, assuming both keep their current
+// velocity, track and vertical rate. Vertical separation is based on barometric altitude. If the vehicles are already
+// moving apart, the closest approach is now. The positions are projected onto a plane around their midpoint, which is
+// accurate for the distances at which a closest approach is of interest.
+func ClosestApproach(a, b *State) (t time.Duration, distMeters float64, err error) {
+	if !a.HasPosition() || !b.HasPosition() {
+		return 0, 0, fmt.Errorf("closest approach of %s and %s: %w", a.Icao24, b.Icao24, ErrNoPosition)
+	}
+
+	// Relative position of b to a in meters, east, north and up.
+	midLat := radians((a.Latitude + b.Latitude) / 2)
+	dLon := math.Mod(b.Longitude-a.Longitude+540, 360) - 180
+	r := [3]float64{
+		radians(dLon) * math.Cos(midLat) * earthRadiusKm * 1000,
+		radians(b.Latitude-a.Latitude) * earthRadiusKm * 1000,
+		b.BaroAltitude - a.BaroAltitude,
+	}
+
+	// Relative velocity of b to a in m/s.
+	va, vb := velocityVector(a), velocityVector(b)
+	w := [3]float64{vb[0] - va[0], vb[1] - va[1], vb[2] - va[2]}
+
+	var seconds float64
+	if ww := dot(w, w); ww > 0 {
+		seconds = math.Max(-dot(r, w)/ww, 0)
+	}
+
+	d := [3]float64{r[0] + w[0]*seconds, r[1] + w[1]*seconds, r[2] + w[2]*seconds}
+	return time.Duration(seconds * float64(time.Second)), math.Sqrt(dot(d, d)), nil
+}
+
+func velocityVector(s *State) [3]float64 {
+	track := radians(s.TrueTrack)
+	return [3]float64{s.Velocity * math.Sin(track), s.Velocity * math.Cos(track), s.VerticalRate}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+// Midpoint returns the point halfway between two vehicles along the great circle connecting them.
+func Midpoint(a, b *State) (lat, lon float64, err error) {
+	if !a.HasPosition() || !b.HasPosition() {
+		return 0, 0, fmt.Errorf("midpoint of %s and %s: %w", a.Icao24, b.Icao24, ErrNoPosition)
+	}
+
+	lat1, lon1 := radians(a.Latitude), radians(a.Longitude)
+	lat2, dLon := radians(b.Latitude), radians(b.Longitude-a.Longitude)
+	bx, by := math.Cos(lat2)*math.Cos(dLon), math.Cos(lat2)*math.Sin(dLon)
+
+	lat = math.Atan2(math.Sin(lat1)+math.Sin(lat2), math.Sqrt((math.Cos(lat1)+bx)*(math.Cos(lat1)+bx)+by*by))
+	lon = lon1 + math.Atan2(by, math.Cos(lat1)+bx)
+	return degrees(lat), math.Mod(degrees(lon)+540, 360) - 180, nil
+}
+
+// ETATo estimates when the vehicle reaches the given point, assuming it flies there along a great circle at its current
+// velocity. It returns ErrHeadingAway if the current track deviates from the bearing to the point by more than 90
+// degrees.
+func (s *State) ETATo(lat, lon float64) (time.Duration, error) {
+	switch {
+	case !s.HasPosition():
+		return 0, fmt.Errorf("ETA of %s: %w", s.Icao24, ErrNoPosition)
+	case s.Velocity <= 0:
+		return 0, fmt.Errorf("ETA of %s: %w", s.Icao24, ErrNoVelocity)
+	}
+
+	from, to := Point{Latitude: s.Latitude, Longitude: s.Longitude}, Point{Latitude: lat, Longitude: lon}
+	if off := math.Abs(math.Mod(initialBearing(from, to)-s.TrueTrack+540, 360) - 180); off > 90 {
+		return 0, fmt.Errorf("ETA of %s: %w", s.Icao24, ErrHeadingAway)
+	}
+	seconds := distanceKm(from, to) * 1000 / s.Velocity
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Centroid returns the geographic center of the positioned states and the distance from it to the farthest of them.
+// Positions are averaged as vectors on the sphere, so traffic around a pole or across the antimeridian is centered
+// correctly. ok is false if no state has a position or the positions cancel out, e.g. two antipodal ones.
+func (r *Response) Centroid() (lat, lon float64, radiusKm float64, ok bool) {
+	var x, y, z float64
+	var points []Point
+	for _, s := range r.States {
+		if !s.HasPosition() {
+			continue
+		}
+		p := Point{Latitude: s.Latitude, Longitude: s.Longitude}
+		points = append(points, p)
+
+		phi, lambda := radians(p.Latitude), radians(p.Longitude)
+		x += math.Cos(phi) * math.Cos(lambda)
+		y += math.Cos(phi) * math.Sin(lambda)
+		z += math.Sin(phi)
+	}
+
+	norm := math.Sqrt(x*x + y*y + z*z)
+	if len(points) == 0 || norm < 1e-9*float64(len(points)) {
+		return 0, 0, 0, false
+	}
+
+	center := Point{Latitude: degrees(math.Asin(z / norm)), Longitude: degrees(math.Atan2(y, x))}
+	for _, p := range points {
+		radiusKm = math.Max(radiusKm, distanceKm(center, p))
+	}
+	return center.Latitude, center.Longitude, radiusKm, true
+}
@@ -0,0 +1,100 @@
+package gopensky
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestClosestApproach(t *testing.T) {
+	// Head-on on the equator, 0.2 degrees (about 22.2 km) apart with 1000 m vertical separation.
+	a := &State{TimePosition: 1545462879, Longitude: -0.1, BaroAltitude: 10000, Velocity: 200, TrueTrack: 90}
+	b := &State{TimePosition: 1545462879, Longitude: 0.1, BaroAltitude: 11000, Velocity: 200, TrueTrack: 270}
+
+	tca, dist, err := ClosestApproach(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(tca.Seconds()-55.6) > 0.1 || math.Abs(dist-1000) > 1 {
+		t.Errorf("got closest approach of %.1f m in %v, want 1000 m in about 55.6s", dist, tca)
+	}
+
+	// Moving apart, the closest approach is now.
+	a.TrueTrack, b.TrueTrack = 270, 90
+	if tca, _, _ := ClosestApproach(a, b); tca != 0 {
+		t.Errorf("got closest approach in %v, want now", tca)
+	}
+
+	if _, _, err := ClosestApproach(a, &State{TimePosition: NoTimePosition}); !errors.Is(err, ErrNoPosition) {
+		t.Errorf("got error %v, want %v", err, ErrNoPosition)
+	}
+}
+
+func TestDestination(t *testing.T) {
+	p := destination(Point{Latitude: 0, Longitude: 179.5}, 90, 111.195)
+	if math.Abs(p.Latitude) > 1e-6 || math.Abs(p.Longitude+179.5) > 1e-3 {
+		t.Errorf("got %+v, want -179.5 across the antimeridian", p)
+	}
+}
+
+func TestCentroid(t *testing.T) {
+	res := &Response{States: []*State{
+		{TimePosition: 1545462879, Latitude: 10, Longitude: 179},
+		{TimePosition: 1545462879, Latitude: 10, Longitude: -179},
+		{TimePosition: NoTimePosition},
+	}}
+
+	lat, lon, radiusKm, ok := res.Centroid()
+	if !ok || math.Abs(lat-10) > 0.01 || math.Abs(math.Abs(lon)-180) > 1e-6 {
+		t.Errorf("got %g, %g, want the antimeridian at 10°", lat, lon)
+	}
+	if math.Abs(radiusKm-109.5) > 0.5 {
+		t.Errorf("got radius %g km, want about 109.5", radiusKm)
+	}
+
+	if _, _, _, ok := (&Response{}).Centroid(); ok {
+		t.Error("want no centroid without positions")
+	}
+}
+
+func TestETATo(t *testing.T) {
+	// One degree of latitude north at 100 m/s.
+	s := &State{Icao24: "8076c4", TimePosition: 1545462879, Latitude: 28, Longitude: 77, Velocity: 100, TrueTrack: 10}
+
+	eta, err := s.ETATo(29, 77)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1112 * time.Second; eta.Round(time.Second) != want {
+		t.Errorf("got %v, want %v", eta, want)
+	}
+
+	if _, err := s.ETATo(27, 77); !errors.Is(err, ErrHeadingAway) {
+		t.Errorf("got %v, want ErrHeadingAway", err)
+	}
+	if _, err := (&State{TimePosition: NoTimePosition}).ETATo(29, 77); !errors.Is(err, ErrNoPosition) {
+		t.Errorf("got %v, want ErrNoPosition", err)
+	}
+	if _, err := (&State{TimePosition: 1545462879}).ETATo(29, 77); !errors.Is(err, ErrNoVelocity) {
+		t.Errorf("got %v, want ErrNoVelocity", err)
+	}
+}
+
+func TestMidpoint(t *testing.T) {
+	a := &State{Icao24: "8076c4", TimePosition: 1545462879, Latitude: 10, Longitude: 179}
+	b := &State{Icao24: "aa56da", TimePosition: 1545462879, Latitude: 10, Longitude: -179}
+
+	lat, lon, err := Midpoint(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The great circle bulges poleward of the parallel.
+	if lat <= 10 || lat > 10.01 || math.Abs(math.Abs(lon)-180) > 1e-6 {
+		t.Errorf("got %g, %g, want just north of 10 on the antimeridian", lat, lon)
+	}
+
+	if _, _, err := Midpoint(a, &State{TimePosition: NoTimePosition}); !errors.Is(err, ErrNoPosition) {
+		t.Errorf("got %v, want ErrNoPosition", err)
+	}
+}
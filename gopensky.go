@@ -2,20 +2,58 @@
 package gopensky
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const Root = "https://opensky-network.org/api"
 
+// ErrTruncatedResponse is returned when the body of a response ends before the JSON document does, e.g. because the
+// connection dropped. Unlike malformed JSON, this is usually worth retrying.
+var ErrTruncatedResponse = errors.New("truncated response")
+
+// ErrEmptyBody is returned when a response is OK but has no body at all, which misconfigured proxies and CDNs
+// occasionally send. Like ErrTruncatedResponse, this is usually worth retrying.
+var ErrEmptyBody = errors.New("empty response body")
+
+// ErrShortStateVector is returned when a state vector has fewer elements than the 17 that OpenSky always sends.
+var ErrShortStateVector = errors.New("short state vector")
+
+// ErrTimeMismatch is returned with WithTimeToleranceCheck when OpenSky served states for a time further from the
+// requested one than the tolerance.
+var ErrTimeMismatch = errors.New("response time differs from requested time")
+
+// Api is a client for the OpenSky REST API. Implementations returned by this package are safe for concurrent use by
+// multiple goroutines, so a single instance can be shared across a program.
 type Api interface {
 	Get(req *Request) (*Response, error)
 }
 
+// ContextApi is implemented by Apis whose requests can be cancelled with a context, which includes all Apis returned
+// by this package. The helpers taking an Api use GetContext when it is implemented and fall back to Get otherwise.
+type ContextApi interface {
+	Api
+	GetContext(ctx context.Context, req *Request) (*Response, error)
+}
+
+// getContext requests the states with ctx if api supports it, or without it otherwise.
+func getContext(ctx context.Context, api Api, req *Request) (*Response, error) {
+	if c, ok := api.(ContextApi); ok {
+		return c.GetContext(ctx, req)
+	}
+	return api.Get(req)
+}
+
 type Request struct {
 	// The time in seconds since epoch (Unix timestamp to retrieve states for. Current time will be used if omitted.
 	Time int
@@ -23,7 +61,39 @@ type Request struct {
 	// append the property once for each address. If omitted, the state vectors of all aircraft are returned.
 	Icao24 []string
 
+	// Area to retrieve states within. When combined with Icao24, the states positioned outside the box are also dropped
+	// by the client, and the box is only sent to OpenSky if it lowers the credit cost.
 	Bbox *Bbox
+
+	// Request the aircraft category of each state vector. The category is only populated when this is set.
+	Extended bool
+
+	// Additional query parameters, e.g. ones OpenSky added that are not modeled yet. They are applied last and replace
+	// any parameter of the same name generated from the other fields.
+	Extra url.Values
+}
+
+// Clone returns a deep copy of the request, so that the copy can be modified without affecting the original.
+func (r *Request) Clone() *Request {
+	if r == nil {
+		return nil
+	}
+
+	c := *r
+	if r.Icao24 != nil {
+		c.Icao24 = append([]string(nil), r.Icao24...)
+	}
+	if r.Bbox != nil {
+		bbox := *r.Bbox
+		c.Bbox = &bbox
+	}
+	if r.Extra != nil {
+		c.Extra = make(url.Values, len(r.Extra))
+		for k, vals := range r.Extra {
+			c.Extra[k] = append([]string(nil), vals...)
+		}
+	}
+	return &c
 }
 
 type Bbox struct {
@@ -43,17 +113,25 @@ type Response struct {
 	Time int
 	// The state vectors.
 	States []*State
+	// Headers of the HTTP response. Only populated with WithCaptureHeaders.
+	Headers http.Header
+	// API credits the request cost, including retries. OpenSky does not report the cost of a request, so this is an
+	// estimate from the credit tier of the bounding box area.
+	CreditsUsed int
+	// Number of state vectors dropped for having fewer than 17 elements. Only set with WithLenientParsing, as such
+	// vectors fail the request otherwise.
+	SkippedCount int
 }
 
 type State struct {
-	// Unique ICAO 24-bit address of the transponder in hex string representation.
+	// Unique ICAO 24-bit address of the transponder in lowercase hex string representation.
 	Icao24 string
 	// Callsign of the vehicle (8 chars). Can be null if no callsign has been received.
 	Callsign string
-	// Country name inferred from the ICAO 24-bit address.
+	// Country name inferred from the ICAO 24-bit address. Empty if OpenSky sent null.
 	OriginCountry string
-	// Unix timestamp (seconds) for the last position update. Can be null if no position report was received by OpenSky
-	// within the past 15s.
+	// Unix timestamp (seconds) for the last position update. NoTimePosition if no position report was received by
+	// OpenSky within the past 15s, in which case the position is not set either.
 	TimePosition int
 	// Unix timestamp (seconds) for the last update in general. This field is updated for any new, valid message received
 	// from the transponder.
@@ -82,42 +160,307 @@ type State struct {
 	Squawk string
 	// Whether flight status indicates special purpose indicator.
 	Spi bool
-	// Origin of this state’s position: 0 = ADS-B, 1 = ASTERIX, 2 = MLAT
-	PositionSource int
+	// Origin of this state’s position.
+	PositionSource PositionSource
+	// Aircraft category. Only populated if the request was extended.
+	Category Category
+	// Elements of the state vector after those modeled here, as decoded from JSON, e.g. ones newly added by OpenSky.
+	Extra []interface{}
+	// Problems with elements of the state vector that could not be parsed and were left at their zero value. Only
+	// populated when parsing leniently.
+	ParseWarnings []string
+}
+
+// Category is the aircraft category reported by the transponder.
+type Category int
+
+const (
+	CategoryNoInfo Category = iota
+	CategoryNoADSBInfo
+	CategoryLight
+	CategorySmall
+	CategoryLarge
+	CategoryHighVortexLarge
+	CategoryHeavy
+	CategoryHighPerformance
+	CategoryRotorcraft
+	CategoryGlider
+	CategoryLighterThanAir
+	CategoryParachutist
+	CategoryUltralight
+	CategoryReserved
+	CategoryUAV
+	CategorySpace
+	CategoryEmergencyVehicle
+	CategoryServiceVehicle
+	CategoryPointObstacle
+	CategoryClusterObstacle
+	CategoryLineObstacle
+)
+
+var categoryNames = [...]string{
+	CategoryNoInfo:           "No information",
+	CategoryNoADSBInfo:       "No ADS-B emitter category information",
+	CategoryLight:            "Light",
+	CategorySmall:            "Small",
+	CategoryLarge:            "Large",
+	CategoryHighVortexLarge:  "High vortex large",
+	CategoryHeavy:            "Heavy",
+	CategoryHighPerformance:  "High performance",
+	CategoryRotorcraft:       "Rotorcraft",
+	CategoryGlider:           "Glider / sailplane",
+	CategoryLighterThanAir:   "Lighter-than-air",
+	CategoryParachutist:      "Parachutist / skydiver",
+	CategoryUltralight:       "Ultralight / hang-glider / paraglider",
+	CategoryReserved:         "Reserved",
+	CategoryUAV:              "Unmanned aerial vehicle",
+	CategorySpace:            "Space / trans-atmospheric vehicle",
+	CategoryEmergencyVehicle: "Surface vehicle – emergency vehicle",
+	CategoryServiceVehicle:   "Surface vehicle – service vehicle",
+	CategoryPointObstacle:    "Point obstacle",
+	CategoryClusterObstacle:  "Cluster obstacle",
+	CategoryLineObstacle:     "Line obstacle",
+}
+
+func (c Category) String() string {
+	if c < 0 || int(c) >= len(categoryNames) {
+		return "Category(" + strconv.Itoa(int(c)) + ")"
+	}
+	return categoryNames[c]
+}
+
+// PositionSource is the origin of the position of a state.
+type PositionSource int
+
+const (
+	SourceADSB PositionSource = iota
+	SourceASTERIX
+	SourceMLAT
+)
+
+func (p PositionSource) String() string {
+	switch p {
+	case SourceADSB:
+		return "ADS-B"
+	case SourceASTERIX:
+		return "ASTERIX"
+	case SourceMLAT:
+		return "MLAT"
+	default:
+		return "PositionSource(" + strconv.Itoa(int(p)) + ")"
+	}
 }
 
 type api struct {
 	Http *http.Client
+
+	token    *tokenSource
+	username string
+	password string
+	header   http.Header
+	defaults *Request
+
+	decoder        Decoder
+	inFlight       chan struct{}
+	lenient        bool
+	captureHeaders bool
+
+	timeTolerance time.Duration
+
+	retryOnEmpty int
+	retryDelay   time.Duration
+	backoff      func(attempt int) time.Duration
 }
 
-func New(httpClient *http.Client) Api {
-	return &api{httpClient}
+// New returns an Api that sends its requests with httpClient, configured by the given options.
+func New(httpClient *http.Client, opts ...Option) Api {
+	a := &api{Http: httpClient}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 func (a *api) Get(req *Request) (*Response, error) {
+	return a.GetContext(context.Background(), req)
+}
+
+func (a *api) GetContext(ctx context.Context, req *Request) (*Response, error) {
+	req = a.withDefaults(req)
+
+	// OpenSky's handling of both filters at once is unreliable, so the box is applied to the requested aircraft here.
+	// It is only left out of the request if that costs no more credits, which boxes below the top tier would.
+	var within *Bbox
+	if req != nil && req.Bbox != nil && len(req.Icao24) > 0 {
+		if err := req.Bbox.Validate(); err != nil {
+			return nil, err
+		}
+		within = req.Bbox
+		byIcao24 := *req
+		byIcao24.Bbox = nil
+		if creditCost(&byIcao24) <= creditCost(req) {
+			req = &byIcao24
+		}
+	}
+
+	credits := 0
+	for attempt := 1; ; attempt++ {
+		res, err := a.fetch(ctx, req)
+		switch {
+		case errors.Is(err, ErrEmptyBody) && attempt < a.retryOnEmpty:
+			// Retried like a response without states. No states were served, so no credits are counted.
+		case err != nil:
+			return nil, err
+		case len(res.States) > 0 || attempt >= a.retryOnEmpty:
+			if err := a.checkTime(req, res); err != nil {
+				return nil, err
+			}
+			if within != nil {
+				res.States = res.WithinBbox(within)
+			}
+			res.CreditsUsed = credits + creditCost(req)
+			return res, nil
+		default:
+			credits += creditCost(req)
+		}
+
+		delay := a.retryDelay
+		if a.backoff != nil {
+			delay = a.backoff(attempt)
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (a *api) fetch(ctx context.Context, req *Request) (*Response, error) {
+	release, err := a.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	res, u, err := a.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	raw, err := a.decode(res.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return nil, fmt.Errorf("GET %s: %w", u, ErrTruncatedResponse)
+		case errors.Is(err, io.EOF):
+			return nil, fmt.Errorf("GET %s: %w", u, ErrEmptyBody)
+		}
+		return nil, err
+	}
+
+	resp, err := deserializeResponse(raw, a.lenient)
+	if err != nil {
+		return nil, err
+	}
+	if a.captureHeaders {
+		resp.Headers = res.Header.Clone()
+	}
+	return resp, nil
+}
+
+// checkTime returns ErrTimeMismatch if res is for a time further from the one requested than the tolerance.
+func (a *api) checkTime(req *Request, res *Response) error {
+	if a.timeTolerance <= 0 || req == nil || req.Time == 0 {
+		return nil
+	}
+	drift := time.Duration(res.Time-req.Time) * time.Second
+	if drift.Abs() > a.timeTolerance {
+		return fmt.Errorf("%w: requested %d, got %d", ErrTimeMismatch, req.Time, res.Time)
+	}
+	return nil
+}
+
+// acquire waits for a slot among the requests in flight allowed by WithMaxConcurrency. The returned function frees the
+// slot.
+func (a *api) acquire(ctx context.Context) (release func(), err error) {
+	if a.inFlight == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case a.inFlight <- struct{}{}:
+		return func() { <-a.inFlight }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// decode decodes a response body with the configured Decoder, or else streams it through encoding/json.
+func (a *api) decode(body io.Reader) (raw map[string]interface{}, err error) {
+	if a.decoder == nil {
+		err = json.NewDecoder(body).Decode(&raw)
+		return raw, err
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, io.EOF
+	}
+	err = a.decoder.Unmarshal(data, &raw)
+	return raw, err
+}
+
+// send sends req to /states/all and returns the response if it is OK, along with the requested URL. The caller must
+// close the response body.
+func (a *api) send(ctx context.Context, req *Request) (*http.Response, string, error) {
+	if req != nil && req.Bbox != nil {
+		if err := req.Bbox.Validate(); err != nil {
+			return nil, "", err
+		}
+	}
+
 	u := endpointFor("states", "all")
 	if req != nil {
 		u.RawQuery = serializeQueryParams(req)
 	}
 
-	res, err := a.Http.Get(u.String())
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	for key, values := range a.header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	if err := a.authorize(ctx, httpReq); err != nil {
+		return nil, "", err
+	}
+	res, err := a.Http.Do(httpReq)
+	if err != nil {
+		return nil, "", err
 	}
 	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("GET %s not OK: %s", u.String(), res.Status)
+		res.Body.Close()
+		return nil, "", fmt.Errorf("GET %s not OK: %s", u.String(), res.Status)
 	}
+	return res, u.String(), nil
+}
 
-	var raw map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&raw); err != nil {
-		return nil, err
-	}
-	states := deserializeStates(raw["states"].([]interface{}))
+// sleep waits for d or until ctx is done, whichever happens first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
 
-	return &Response{
-		Time:   int(raw["time"].(float64)),
-		States: states,
-	}, nil
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
 }
 
 func endpointFor(path ...string) (u *url.URL) {
@@ -136,7 +479,12 @@ func serializeQueryParams(req *Request) string {
 		v.Set("time", strconv.Itoa(req.Time))
 	}
 
+	seen := make(map[string]bool, len(req.Icao24))
 	for _, icao24 := range req.Icao24 {
+		if seen[icao24] {
+			continue
+		}
+		seen[icao24] = true
 		v.Add("icao24", icao24)
 	}
 
@@ -147,60 +495,246 @@ func serializeQueryParams(req *Request) string {
 		v.Set("lomax", formatDegrees(req.Bbox.Lomax))
 	}
 
+	if req.Extended {
+		v.Set("extended", "1")
+	}
+
+	for k, vals := range req.Extra {
+		v[k] = append([]string(nil), vals...)
+	}
+
 	return v.Encode()
 }
 
-func deserializeStates(rawStates []interface{}) []*State {
-	states := make([]*State, 0)
-	for _, rawState := range rawStates {
-		states = append(states, deserializeState(rawState))
+func deserializeResponse(raw map[string]interface{}, lenient bool) (res *Response, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed response: %v", r)
+		}
+	}()
+
+	// OpenSky sends null rather than an empty array if there are no states.
+	rawStates, ok := raw["states"].([]interface{})
+	if !ok && raw["states"] != nil {
+		return nil, fmt.Errorf("malformed response: expected states to be an array, got %T", raw["states"])
+	}
+
+	timestamp, ok := parseTimestamp(raw["time"])
+	if !ok {
+		return nil, fmt.Errorf("malformed response: expected time to be a number, got %T", raw["time"])
+	}
+
+	states, skipped, err := deserializeStates(rawStates, lenient)
+	if err != nil {
+		return nil, err
 	}
-	return states
+
+	return &Response{
+		Time:         timestamp,
+		States:       states,
+		SkippedCount: skipped,
+	}, nil
 }
 
-func deserializeState(state interface{}) *State {
-	vec := state.([]interface{})
-	return &State{
-		Icao24:         vec[0].(string),
-		Callsign:       deserializeString(vec[1]),
-		OriginCountry:  vec[2].(string),
-		TimePosition:   int(deserializeFloat64(vec[3])),
-		LastContact:    int(vec[4].(float64)),
-		Longitude:      deserializeFloat64(vec[5]),
-		Latitude:       deserializeFloat64(vec[6]),
-		BaroAltitude:   deserializeFloat64(vec[7]),
-		OnGround:       vec[8].(bool),
-		Velocity:       deserializeFloat64(vec[9]),
-		TrueTrack:      deserializeFloat64(vec[10]),
-		VerticalRate:   deserializeFloat64(vec[11]),
-		Sensors:        deserializeIntSlice(vec[12]),
-		GeoAltitude:    deserializeFloat64(vec[13]),
-		Squawk:         deserializeString(vec[14]),
-		Spi:            vec[15].(bool),
-		PositionSource: int(vec[16].(float64)),
+// parseTimestamp parses a Unix timestamp decoded from JSON, which depending on the endpoint and the decoder can be a
+// float, an integer or a json.Number.
+func parseTimestamp(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i), true
+		}
+		f, err := v.Float64()
+		return int(f), err == nil
+	default:
+		return 0, false
 	}
 }
 
-func deserializeIntSlice(slice interface{}) []int {
-	if slice == nil {
+// deserializeStates deserializes the state vectors of a response. When lenient, short state vectors are skipped and
+// counted rather than failing the response.
+func deserializeStates(rawStates []interface{}, lenient bool) (states []*State, skipped int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			states, skipped, err = nil, 0, fmt.Errorf("malformed state vector %d: %v", len(states)+skipped, r)
+		}
+	}()
+
+	states = make([]*State, 0)
+	for i, rawState := range rawStates {
+		state, err := deserializeState(rawState)
+		if errors.Is(err, ErrShortStateVector) && lenient {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("malformed state vector %d: %w", i, err)
+		}
+		if len(state.ParseWarnings) > 0 && !lenient {
+			return nil, 0, fmt.Errorf("malformed state vector %d: %s", i, strings.Join(state.ParseWarnings, "; "))
+		}
+		states = append(states, state)
+	}
+	return states, skipped, nil
+}
+
+// minStateFields is the number of elements OpenSky sends in every state vector. Extended requests add the category.
+const minStateFields = 17
+
+// Names of the elements of a state vector as documented by OpenSky.
+var stateFields = [...]string{
+	"icao24", "callsign", "origin_country", "time_position", "last_contact", "longitude", "latitude", "baro_altitude",
+	"on_ground", "velocity", "true_track", "vertical_rate", "sensors", "geo_altitude", "squawk", "spi",
+	"position_source", "category",
+}
+
+// stateParser deserializes the elements of a state vector, recording the elements that could not be parsed instead of
+// failing on the first one.
+type stateParser struct {
+	vec      []interface{}
+	warnings []string
+}
+
+func (p *stateParser) warn(i int, want string) {
+	p.warnings = append(p.warnings, fmt.Sprintf("%s: expected %s, got %v", stateFields[i], want, p.vec[i]))
+}
+
+func deserializeState(state interface{}) (*State, error) {
+	vec, ok := state.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got %T", state)
+	}
+	if len(vec) < minStateFields {
+		return nil, fmt.Errorf("%w: expected at least %d elements, got %d", ErrShortStateVector, minStateFields, len(vec))
+	}
+
+	p := &stateParser{vec: vec}
+	s := &State{
+		Icao24:         strings.ToLower(p.requiredString(0)),
+		Callsign:       p.string(1),
+		OriginCountry:  p.string(2),
+		TimePosition:   p.timestamp(3, NoTimePosition),
+		LastContact:    p.requiredTimestamp(4),
+		Longitude:      p.float64(5),
+		Latitude:       p.float64(6),
+		BaroAltitude:   p.float64(7),
+		OnGround:       p.bool(8),
+		Velocity:       p.float64(9),
+		TrueTrack:      p.float64(10),
+		VerticalRate:   p.float64(11),
+		Sensors:        p.intSlice(12),
+		GeoAltitude:    p.float64(13),
+		Squawk:         p.string(14),
+		Spi:            p.bool(15),
+		PositionSource: PositionSource(p.requiredFloat64(16)),
+	}
+	if len(vec) > minStateFields {
+		s.Category = Category(p.float64(17))
+	}
+	if len(vec) > len(stateFields) {
+		s.Extra = slices.Clone(vec[len(stateFields):])
+	}
+	s.ParseWarnings = p.warnings
+	return s, nil
+}
+
+func (p *stateParser) intSlice(i int) []int {
+	if p.vec[i] == nil {
 		return []int{}
 	}
 
-	return slice.([]int)
+	raw, ok := p.vec[i].([]interface{})
+	if !ok {
+		p.warn(i, "array")
+		return []int{}
+	}
+	ints := make([]int, 0, len(raw))
+	for _, v := range raw {
+		f, ok := v.(float64)
+		if !ok {
+			p.warn(i, "array of numbers")
+			return []int{}
+		}
+		ints = append(ints, int(f))
+	}
+	return ints
 }
 
-func deserializeString(str interface{}) string {
-	if str == nil {
+// timestamp parses a nullable Unix timestamp, returning null if the element is null.
+func (p *stateParser) timestamp(i int, null int) int {
+	if p.vec[i] == nil {
+		return null
+	}
+
+	return p.requiredTimestamp(i)
+}
+
+// Range of plausible timestamps in state vectors, from 2000 to 2100. Timestamps outside of it come from corrupted
+// payloads.
+const (
+	minStateTimestamp = 946684800
+	maxStateTimestamp = 4102444800
+)
+
+func (p *stateParser) requiredTimestamp(i int) int {
+	t, ok := parseTimestamp(p.vec[i])
+	switch {
+	case !ok:
+		p.warn(i, "timestamp")
+	case t < minStateTimestamp || t >= maxStateTimestamp:
+		p.warn(i, "timestamp between 2000 and 2100")
+		return 0
+	}
+	return t
+}
+
+func (p *stateParser) string(i int) string {
+	if p.vec[i] == nil {
 		return ""
 	}
 
-	return str.(string)
+	return p.requiredString(i)
+}
+
+func (p *stateParser) requiredString(i int) string {
+	str, ok := p.vec[i].(string)
+	if !ok {
+		p.warn(i, "string")
+	}
+	return str
 }
 
-func deserializeFloat64(f64 interface{}) float64 {
-	if f64 == nil {
+func (p *stateParser) float64(i int) float64 {
+	if p.vec[i] == nil {
 		return 0
 	}
 
-	return f64.(float64)
+	return p.requiredFloat64(i)
+}
+
+func (p *stateParser) requiredFloat64(i int) float64 {
+	f64, ok := p.vec[i].(float64)
+	if !ok {
+		p.warn(i, "number")
+	}
+	return f64
+}
+
+// bool parses a boolean, treating null as false.
+func (p *stateParser) bool(i int) bool {
+	if p.vec[i] == nil {
+		return false
+	}
+
+	b, ok := p.vec[i].(bool)
+	if !ok {
+		p.warn(i, "boolean")
+	}
+	return b
 }
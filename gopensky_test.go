@@ -1,14 +1,48 @@
 package gopensky
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
-func TestDeserializeStates(t *testing.T) {
-	f, err := os.Open(filepath.Join("testdata", "sample.json"))
+// roundTripFunc serves requests in tests without touching the network.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newSampleClient returns an http.Client that answers every request with testdata/sample.json.
+func newSampleClient(t testing.TB) *http.Client {
+	body, err := os.ReadFile(filepath.Join("testdata", "sample.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+func loadTestdata(t *testing.T, name string) map[string]interface{} {
+	f, err := os.Open(filepath.Join("testdata", name))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -18,6 +52,311 @@ func TestDeserializeStates(t *testing.T) {
 	if err := json.NewDecoder(f).Decode(&raw); err != nil {
 		t.Fatal(err)
 	}
+	return raw
+}
+
+func TestDeserializeStates(t *testing.T) {
+	raw := loadTestdata(t, "sample.json")
+
+	if _, _, err := deserializeStates(raw["states"].([]interface{}), false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeserializeUppercaseIcao24(t *testing.T) {
+	res, err := deserializeResponse(loadTestdata(t, "uppercase_icao24.json"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := res.States[0].Icao24; got != "3c6444" {
+		t.Errorf("got icao24 %q, want %q", got, "3c6444")
+	}
+}
+
+func TestDeserializeMalformedStates(t *testing.T) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"time":1545462880,"states":[["8076c4",null,42]]}`), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := deserializeResponse(raw, false); err == nil {
+		t.Error("expected an error for a malformed state vector")
+	}
+}
+
+func TestDeserializeExtendedState(t *testing.T) {
+	var vec []interface{}
+	raw := `["c03a4b","WJA123  ","Canada",1545462879,1545462879,-79.6,43.6,1200,false,90,45,5,null,1250,"1200",false,0,8]`
+	if err := json.Unmarshal([]byte(raw), &vec); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := deserializeState(vec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Category != CategoryRotorcraft {
+		t.Errorf("got category %v, want %v", state.Category, CategoryRotorcraft)
+	}
+}
+
+func TestSerializeExtended(t *testing.T) {
+	if got := serializeQueryParams(&Request{Extended: true}); got != "extended=1" {
+		t.Errorf("got %q, want %q", got, "extended=1")
+	}
+}
+
+func TestConcurrentGet(t *testing.T) {
+	api := New(newSampleClient(t))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := api.Get(&Request{Icao24: []string{"8076c4"}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(res.States) == 0 {
+				t.Error("got no states")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRequestClone(t *testing.T) {
+	req := &Request{Time: 1545462880, Icao24: []string{"8076c4", "aa56da"}, Bbox: &Bbox{Lamin: 1, Lomin: 2, Lamax: 3, Lomax: 4}, Extra: url.Values{"serials": {"1"}}}
+	clone := req.Clone()
+	clone.Extra.Add("serials", "2")
+
+	clone.Icao24[0] = "7c6b2f"
+	clone.Icao24 = append(clone.Icao24, "7c6b2b")
+	clone.Bbox.Lamin = 0
+
+	if req.Icao24[0] != "8076c4" || len(req.Icao24) != 2 {
+		t.Errorf("modifying the clone changed the original icao24 filter: %v", req.Icao24)
+	}
+	if req.Bbox.Lamin != 1 {
+		t.Errorf("modifying the clone changed the original bbox: %+v", req.Bbox)
+	}
+	if len(req.Extra["serials"]) != 1 {
+		t.Errorf("modifying the clone changed the original extra parameters: %v", req.Extra)
+	}
+}
+
+func TestSerializeDuplicateIcao24(t *testing.T) {
+	got := serializeQueryParams(&Request{Icao24: []string{"aa56da", "8076c4", "aa56da"}})
+	if want := "icao24=aa56da&icao24=8076c4"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDeserializeLenient(t *testing.T) {
+	var rawStates []interface{}
+	raw := `[["8076c4","JAI824  ","India",1545462879,1545462879,"77.4861",28.4392,1744.98,false,110.61,316.13,-3.58,[12,"x"],1767.84,"2701",false,0]]`
+	if err := json.Unmarshal([]byte(raw), &rawStates); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := deserializeStates(rawStates, false); err == nil {
+		t.Error("expected an error when parsing strictly")
+	}
+
+	states, _, err := deserializeStates(rawStates, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := states[0]
+	if s.Longitude != 0 || s.Latitude != 28.4392 || len(s.Sensors) != 0 || s.Squawk != "2701" {
+		t.Errorf("got %+v, want unparseable fields zeroed and the rest populated", s)
+	}
+	if len(s.ParseWarnings) != 2 {
+		t.Errorf("got warnings %q, want one for longitude and one for sensors", s.ParseWarnings)
+	}
+}
+
+func TestDeserializeNullTimePosition(t *testing.T) {
+	var vec []interface{}
+	raw := `["aa56da","UAL482  ","United States",null,1545462646,null,null,868.68,false,23.66,264,null,null,883.92,"3777",false,0]`
+	if err := json.Unmarshal([]byte(raw), &vec); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := deserializeState(vec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.TimePosition != NoTimePosition || state.HasPosition() {
+		t.Errorf("got time position %d, want %d and no position", state.TimePosition, NoTimePosition)
+	}
+}
+
+func TestSerializeExtra(t *testing.T) {
+	got := serializeQueryParams(&Request{Extended: true, Extra: url.Values{"extended": {"0"}, "serials": {"1", "2"}}})
+	if want := "extended=0&serials=1&serials=2"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGetTruncatedResponse(t *testing.T) {
+	var calls int
+	if _, err := New(newSequenceClient(&calls, stateBody[:100])).Get(nil); !errors.Is(err, ErrTruncatedResponse) {
+		t.Errorf("got error %v, want %v", err, ErrTruncatedResponse)
+	}
+	if _, err := New(newSequenceClient(&calls, `{"time":}`)).Get(nil); err == nil || errors.Is(err, ErrTruncatedResponse) {
+		t.Errorf("got error %v, want a syntax error", err)
+	}
+}
+
+func TestGetNullStates(t *testing.T) {
+	var calls int
+	res, err := New(newSequenceClient(&calls, `{"time":1545462880,"states":null}`)).Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.States == nil || len(res.States) != 0 {
+		t.Errorf("got states %v, want an empty slice", res.States)
+	}
+}
+
+func TestDeserializeNullBooleans(t *testing.T) {
+	res, err := deserializeResponse(loadTestdata(t, "null_spi.json"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := res.States[0]; s.Spi || s.OnGround || len(s.ParseWarnings) != 0 {
+		t.Errorf("got spi %t, on ground %t, warnings %q, want false without warnings", s.Spi, s.OnGround, s.ParseWarnings)
+	}
+}
+
+func TestDeserializeNumberTimestamps(t *testing.T) {
+	d := json.NewDecoder(strings.NewReader(stateBody))
+	d.UseNumber()
+	var raw map[string]interface{}
+	if err := d.Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	raw["states"] = []interface{}{[]interface{}{"8076c4", nil, "India", 1545462879, int64(1545462879), nil, nil, nil, false, nil, nil, nil, nil, nil, nil, false, 0.0}}
+
+	res, err := deserializeResponse(raw, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.States[0]; res.Time != 1545462890 || s.TimePosition != 1545462879 || s.LastContact != 1545462879 {
+		t.Errorf("got time %d, time position %d, last contact %d", res.Time, s.TimePosition, s.LastContact)
+	}
+
+	if _, err := deserializeResponse(map[string]interface{}{"states": nil}, false); err == nil {
+		t.Error("want an error for a missing time")
+	}
+}
+
+func TestDeserializeNullOriginCountry(t *testing.T) {
+	res, err := deserializeResponse(loadTestdata(t, "null_origin_country.json"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := res.States[0]; s.OriginCountry != "" || len(s.ParseWarnings) != 0 {
+		t.Errorf("got origin country %q, warnings %q, want empty without warnings", s.OriginCountry, s.ParseWarnings)
+	}
+}
+
+func TestDeserializeImplausibleTimestamp(t *testing.T) {
+	var vec []interface{}
+	raw := `["8076c4","JAI824  ","India",1545462879,1.5e19,77.4861,28.4392,1744.98,false,110.61,316.13,-3.58,null,1767.84,"2701",false,0]`
+	if err := json.Unmarshal([]byte(raw), &vec); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := deserializeState(vec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.LastContact != 0 || len(state.ParseWarnings) != 1 {
+		t.Errorf("got last contact %d, warnings %q, want 0 with a warning", state.LastContact, state.ParseWarnings)
+	}
+	if _, _, err := deserializeStates([]interface{}{vec}, false); err == nil {
+		t.Error("want an error in strict mode")
+	}
+}
+
+func TestDeserializeExtraElements(t *testing.T) {
+	body := `{"time":1545462890,"states":[["8076c4","JAI824  ","India",1545462879,1545462879,77.4861,28.4392,1744.98,false,110.61,316.13,-3.58,null,1767.84,"2701",false,0,6,"A320",{"wake":"M"}]]}`
+	var calls int
+	res, err := New(newSequenceClient(&calls, body)).Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []interface{}{"A320", map[string]interface{}{"wake": "M"}}
+	if s := res.States[0]; s.Category != CategoryHeavy || !reflect.DeepEqual(s.Extra, want) {
+		t.Errorf("got category %v and extra %v, want %v", s.Category, s.Extra, want)
+	}
+
+	var buf bytes.Buffer
+	if err := SaveResponse(&buf, res); err != nil {
+		t.Fatal(err)
+	}
 
-	deserializeStates(raw["states"].([]interface{}))
+	var s State
+	if err := GetInto(context.Background(), New(newSequenceClient(&calls, body)), &Request{Icao24: []string{"8076c4"}}, &s); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.Extra, want) {
+		t.Errorf("got extra %v from GetInto, want %v", s.Extra, want)
+	}
+}
+
+func TestCreditsUsed(t *testing.T) {
+	tests := []struct {
+		bbox *Bbox
+		want int
+	}{
+		{nil, 4},
+		{&Bbox{Lamin: 45, Lomin: 5, Lamax: 50, Lomax: 10}, 1},
+		{&Bbox{Lamin: 45, Lomin: 5, Lamax: 55, Lomax: 15}, 2},
+		{&Bbox{Lamin: 40, Lomin: 0, Lamax: 60, Lomax: 20}, 3},
+		{&Bbox{Lamin: 30, Lomin: 0, Lamax: 60, Lomax: 30}, 4},
+	}
+	for _, tt := range tests {
+		var calls int
+		res, err := New(newSequenceClient(&calls, stateBody)).Get(&Request{Bbox: tt.bbox})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.CreditsUsed != tt.want {
+			t.Errorf("got %d credits for %+v, want %d", res.CreditsUsed, tt.bbox, tt.want)
+		}
+	}
+
+	var calls int
+	res, err := New(newSequenceClient(&calls, emptyBody, stateBody), WithRetryOnEmpty(3, 0)).Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CreditsUsed != 8 {
+		t.Errorf("got %d credits for a retried request, want 8", res.CreditsUsed)
+	}
+}
+
+func TestDeserializeShortVector(t *testing.T) {
+	body := `{"time":1545462890,"states":[["8076c4","JAI824  ","India",1545462879,1545462879,77.4861,28.4392,1744.98,false,110.61,316.13,-3.58,null,1767.84,"2701",false,0],["aa56da","UAL482  "]]}`
+	var calls int
+	if _, err := New(newSequenceClient(&calls, body)).Get(nil); !errors.Is(err, ErrShortStateVector) || !strings.Contains(err.Error(), "state vector 1") {
+		t.Errorf("got error %v, want %v naming state vector 1", err, ErrShortStateVector)
+	}
+
+	res, err := New(newSequenceClient(&calls, body), WithLenientParsing()).Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.States) != 1 || res.SkippedCount != 1 {
+		t.Errorf("got %d states and %d skipped, want 1 of each", len(res.States), res.SkippedCount)
+	}
 }
@@ -0,0 +1,159 @@
+package gopensky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// GetInto fetches the current state of the single aircraft requested by req into s, or returns ErrAircraftNotTracked
+// if OpenSky has none. It is meant for polling a few aircraft in a tight loop: for Apis returned by New, the response
+// is decoded straight into s, reusing its sensors slice, rather than through the generic path behind Get, which
+// allocates a new slice of states per call.
+//
+// The fast path parses strictly, does not retry empty responses, does not filter by Bbox and does not check the
+// response time, so requests with a Bbox and Apis configured with WithLenientParsing, WithRetryOnEmpty or
+// WithTimeToleranceCheck, like any other Api implementation, take the generic path.
+func GetInto(ctx context.Context, api Api, req *Request, s *State) error {
+	if req == nil || len(req.Icao24) != 1 {
+		return errors.New("GetInto needs a request for exactly one icao24")
+	}
+	icao24 := strings.ToLower(req.Icao24[0])
+
+	if a, ok := api.(intoFetcher); ok && a.canFetchInto(req) {
+		found, err := a.fetchInto(ctx, req, s)
+		if err != nil {
+			return err
+		}
+		if !found || s.Icao24 != icao24 {
+			return fmt.Errorf("%w: %s", ErrAircraftNotTracked, icao24)
+		}
+		return nil
+	}
+
+	res, err := getContext(ctx, api, req)
+	if err != nil {
+		return err
+	}
+	for _, state := range res.States {
+		if state.Icao24 == icao24 {
+			*s = *state
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrAircraftNotTracked, icao24)
+}
+
+// intoFetcher is implemented by Apis with a fast path for GetInto.
+type intoFetcher interface {
+	canFetchInto(req *Request) bool
+	fetchInto(ctx context.Context, req *Request, s *State) (bool, error)
+}
+
+func (a *api) canFetchInto(req *Request) bool {
+	return !a.lenient && a.retryOnEmpty <= 1 && a.timeTolerance <= 0 && a.withDefaults(req).Bbox == nil
+}
+
+// fetchInto decodes the first state of the response to req into s, reporting whether there was one.
+func (a *api) fetchInto(ctx context.Context, req *Request, s *State) (bool, error) {
+	release, err := a.acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	res, u, err := a.send(ctx, a.withDefaults(req))
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	into := stateInto{States: []stateVectorInto{{s: s}}}
+	if err := json.NewDecoder(res.Body).Decode(&into); err != nil {
+		switch {
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return false, fmt.Errorf("GET %s: %w", u, ErrTruncatedResponse)
+		case errors.Is(err, io.EOF):
+			return false, fmt.Errorf("GET %s: %w", u, ErrEmptyBody)
+		}
+		return false, err
+	}
+	if len(into.States) == 0 {
+		return false, nil
+	}
+	return true, into.States[0].err
+}
+
+// stateInto is a /states/all response whose first state vector is decoded into a given State, skipping the others.
+type stateInto struct {
+	States []stateVectorInto `json:"states"`
+}
+
+type stateVectorInto struct {
+	s      *State
+	fields [len(stateFields)]interface{}
+	// Elements parsed like by the generic path, at their index in the state vector.
+	numbers [len(stateFields)]interface{}
+	err     error
+}
+
+func (v *stateVectorInto) UnmarshalJSON(data []byte) error {
+	if v.s == nil {
+		return nil
+	}
+
+	s := v.s
+	sensors := s.Sensors[:0]
+	*s = State{}
+	v.numbers = [len(stateFields)]interface{}{}
+	n := &v.numbers
+	v.fields = [...]interface{}{
+		&s.Icao24, &s.Callsign, &s.OriginCountry, &n[3], &n[4], &s.Longitude, &s.Latitude,
+		&s.BaroAltitude, &s.OnGround, &s.Velocity, &s.TrueTrack, &s.VerticalRate, &sensors, &s.GeoAltitude,
+		&s.Squawk, &s.Spi, &n[16], &n[17],
+	}
+
+	// Decoding into pointers wrapped in interfaces fills in the fields of s, and sets the elements that are null to
+	// nil.
+	fields := v.fields[:]
+	if err := json.Unmarshal(data, &fields); err != nil {
+		v.err = fmt.Errorf("malformed state vector 0: %v", err)
+		return nil
+	}
+	if len(fields) < minStateFields {
+		v.err = fmt.Errorf("malformed state vector 0: %w: expected at least %d elements, got %d", ErrShortStateVector,
+			minStateFields, len(fields))
+		return nil
+	}
+	for _, i := range [...]int{0, 4, 16} {
+		if fields[i] == nil {
+			v.err = fmt.Errorf("malformed state vector 0: %s: expected a value", stateFields[i])
+			return nil
+		}
+	}
+
+	// Timestamps and enums are parsed like by the generic path, which accepts them encoded as floats.
+	p := &stateParser{vec: n[:]}
+	s.TimePosition = p.timestamp(3, NoTimePosition)
+	s.LastContact = p.requiredTimestamp(4)
+	s.PositionSource = PositionSource(p.requiredFloat64(16))
+	s.Category = Category(p.float64(17))
+	if len(p.warnings) > 0 {
+		v.err = fmt.Errorf("malformed state vector 0: %s", strings.Join(p.warnings, "; "))
+		return nil
+	}
+
+	s.Icao24 = strings.ToLower(s.Icao24)
+	if sensors == nil {
+		sensors = []int{}
+	}
+	s.Sensors = sensors
+	if len(fields) > len(stateFields) {
+		s.Extra = slices.Clone(fields[len(stateFields):])
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package gopensky
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetInto(t *testing.T) {
+	var calls int
+	client := newSequenceClient(&calls, stateBody)
+	req := &Request{Icao24: []string{"8076C4"}}
+
+	want, err := GetAircraft(context.Background(), New(client), "8076c4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, api := range []Api{New(client), New(client, WithLenientParsing())} {
+		s := &State{Icao24: "stale", Sensors: []int{1, 2}, Category: CategoryHeavy}
+		if err := GetInto(context.Background(), api, req, s); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(s, want) {
+			t.Errorf("got %+v, want %+v", s, want)
+		}
+	}
+}
+
+func TestGetIntoFloatTimestamps(t *testing.T) {
+	var calls int
+	body := `{"time":1545462890,"states":[["8076c4","JAI824  ","India",1545462879.0,1545462879.0,77.4861,28.4392,1744.98,false,110.61,316.13,-3.58,null,1767.84,"2701",false,0.0,6.0]]}`
+	api := New(newSequenceClient(&calls, body))
+	req := &Request{Icao24: []string{"8076c4"}}
+
+	res, err := api.Get(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s State
+	if err := GetInto(context.Background(), api, req, &s); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&s, res.States[0]) || s.LastContact != 1545462879 || s.Category != CategoryHeavy {
+		t.Errorf("got %+v, want %+v", s, res.States[0])
+	}
+}
+
+func TestGetIntoNotTracked(t *testing.T) {
+	var calls int
+	api := New(newSequenceClient(&calls, emptyBody))
+
+	err := GetInto(context.Background(), api, &Request{Icao24: []string{"8076c4"}}, &State{})
+	if !errors.Is(err, ErrAircraftNotTracked) {
+		t.Errorf("got %v, want ErrAircraftNotTracked", err)
+	}
+}
+
+func TestGetIntoTimeTolerance(t *testing.T) {
+	var calls int
+	api := New(newSequenceClient(&calls, stateBody), WithTimeToleranceCheck(5*time.Second))
+
+	err := GetInto(context.Background(), api, &Request{Time: 1545462880, Icao24: []string{"8076c4"}}, &State{})
+	if !errors.Is(err, ErrTimeMismatch) {
+		t.Errorf("got %v, want %v", err, ErrTimeMismatch)
+	}
+}
+
+func TestGetIntoMalformed(t *testing.T) {
+	var calls int
+	api := New(newSequenceClient(&calls, `{"time":1545462890,"states":[[null,"JAI824  ","India",1545462879,1545462879,77.4861,28.4392,1744.98,false,110.61,316.13,-3.58,null,1767.84,"2701",false,0]]}`))
+
+	if err := GetInto(context.Background(), api, &Request{Icao24: []string{"8076c4"}}, &State{}); err == nil {
+		t.Error("want an error for a null icao24")
+	}
+
+	api = New(newSequenceClient(&calls, `{"time":1545462890,"states":[["8076c4","JAI824  ","India"]]}`))
+	if err := GetInto(context.Background(), api, &Request{Icao24: []string{"8076c4"}}, &State{}); !errors.Is(err, ErrShortStateVector) {
+		t.Errorf("got %v, want %v", err, ErrShortStateVector)
+	}
+}
+
+func BenchmarkGetAircraft(b *testing.B) {
+	var calls int
+	api := New(newSequenceClient(&calls, stateBody))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetAircraft(context.Background(), api, "8076c4"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetInto(b *testing.B) {
+	var calls int
+	api := New(newSequenceClient(&calls, stateBody))
+	req := &Request{Icao24: []string{"8076c4"}}
+	var s State
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := GetInto(context.Background(), api, req, &s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package gopensky
+
+import "time"
+
+// Limits of the OpenSky REST API as documented by OpenSky.
+const (
+	// How far in the past authenticated users can request states. Anonymous users only get the most recent states,
+	// whatever the requested time.
+	MaxStatesHistory = time.Hour
+
+	// Time resolution of the states served to anonymous and authenticated users.
+	AnonymousTimeResolution     = 10 * time.Second
+	AuthenticatedTimeResolution = 5 * time.Second
+
+	// API credits per day for anonymous users, registered users and users with an active receiver.
+	AnonymousDailyCredits = 400
+	UserDailyCredits      = 4000
+	FeederDailyCredits    = 8000
+)
+
+// Upper bounds, in square degrees, of the bounding box areas that the credit tiers of /states/all apply to. A box of
+// at most CreditTier1Area costs 1 credit, up to CreditTier2Area 2 and up to CreditTier3Area 3. A larger box or none at
+// all costs 4 credits.
+const (
+	CreditTier1Area = 25
+	CreditTier2Area = 100
+	CreditTier3Area = 400
+)
+
+// creditCost returns the credits a request to /states/all costs according to the credit tiers.
+func creditCost(req *Request) int {
+	if req == nil || req.Bbox == nil {
+		return 4
+	}
+
+	area := (req.Bbox.Lamax - req.Bbox.Lamin) * (req.Bbox.Lomax - req.Bbox.Lomin)
+	switch {
+	case area <= CreditTier1Area:
+		return 1
+	case area <= CreditTier2Area:
+		return 2
+	case area <= CreditTier3Area:
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,241 @@
+package gopensky
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Fraction of the interval by which LiveFeed randomly varies the time between polls, so that many feeds started at
+// once don't poll in lockstep.
+const liveFeedJitter = 0.1
+
+// Longest time LiveFeed waits between polls while OpenSky keeps failing, e.g. during maintenance.
+const liveFeedMaxBackoff = 15 * time.Minute
+
+// FeedHealth is whether a LiveFeed is receiving snapshots.
+type FeedHealth int
+
+const (
+	FeedHealthy FeedHealth = iota
+	// The latest poll failed, and the feed backs off until a poll succeeds again.
+	FeedDegraded
+)
+
+func (h FeedHealth) String() string {
+	if h == FeedDegraded {
+		return "degraded"
+	}
+	return "healthy"
+}
+
+// LiveFeed keeps an up-to-date snapshot of the states within a box by polling OpenSky, the building block of a live
+// aircraft map.
+type LiveFeed struct {
+	api      Api
+	bbox     *Bbox
+	interval time.Duration
+
+	updates chan *ResponseDiff
+	health  chan FeedHealth
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu       sync.Mutex
+	snapshot *Response
+	err      error
+	tee      io.Writer
+	format   Format
+}
+
+// NewLiveFeed starts polling api for the states within bbox, or all states if bbox is nil, about every interval. Call
+// Close to stop it.
+//
+// Each poll that brings a newer snapshot with changes is sent on Updates. Polls that return the same snapshot as
+// before, which happens when polling faster than OpenSky's time resolution, are skipped. If an aircraft appears more
+// than once in a snapshot, only its most recent state is kept. Polling never waits for updates to be received.
+//
+// While polls fail, the time between them doubles with every failure up to 15 minutes, so the feed keeps going through
+// long outages without hammering OpenSky, and returns to interval once a poll succeeds.
+func NewLiveFeed(api Api, bbox *Bbox, interval time.Duration) *LiveFeed {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &LiveFeed{
+		api:      api,
+		bbox:     bbox,
+		interval: interval,
+		updates:  make(chan *ResponseDiff, 1),
+		health:   make(chan FeedHealth, 1),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go f.run(ctx)
+	return f
+}
+
+// Snapshot returns the latest snapshot, or nil before the first successful poll.
+func (f *LiveFeed) Snapshot() *Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.snapshot
+}
+
+// Err returns the error of the latest poll, or nil if it succeeded.
+func (f *LiveFeed) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.err
+}
+
+// Updates returns the changes of each new snapshot relative to the previous one. The first update lists all
+// aircraft as added. An update that is not received before the next snapshot is replaced by the changes since the
+// snapshot it started from, so a slow receiver gets fewer, larger updates but never misses a change. The channel is
+// closed by Close.
+func (f *LiveFeed) Updates() <-chan *ResponseDiff {
+	return f.updates
+}
+
+// Health returns the transitions between FeedHealthy and FeedDegraded. A transition that is not received in time is
+// replaced by the next one, so the latest health is always delivered. The channel is closed by Close.
+func (f *LiveFeed) Health() <-chan FeedHealth {
+	return f.health
+}
+
+// TeeTo makes the feed write each new snapshot to w in the given format, e.g. to record the traffic to a file. If w has
+// a Flush method, like a bufio.Writer, it is flushed after every snapshot so that complete snapshots can be picked up
+// or rotated. Write errors, including an unsupported format, are reported by Err. It applies from the next poll on;
+// pass a nil w to stop writing.
+func (f *LiveFeed) TeeTo(w io.Writer, format Format) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tee, f.format = w, format
+}
+
+// Close stops polling and waits for the feed to shut down.
+func (f *LiveFeed) Close() {
+	f.cancel()
+	<-f.done
+}
+
+func (f *LiveFeed) run(ctx context.Context) {
+	defer close(f.done)
+	defer close(f.updates)
+	defer close(f.health)
+
+	// The snapshot the receiver last got an update for, and the one the pending update, if any, started from.
+	var sent, base *Response
+	failures := 0
+	for {
+		res, err := f.poll(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			failures++
+			if failures == 1 {
+				f.setHealth(FeedDegraded)
+			}
+		case failures > 0:
+			failures = 0
+			f.setHealth(FeedHealthy)
+		}
+
+		if res != nil {
+			from := sent
+			select {
+			case <-f.updates:
+				from = base
+			default:
+			}
+			// Run is the only sender, so the update fits in the buffer that was just found or made empty.
+			if diff := Diff(from, res); from == nil || !diff.Empty() {
+				f.updates <- diff
+				base = from
+			}
+			sent = res
+		}
+
+		delay := backoffDelay(f.interval, failures)
+		jitter := time.Duration((rand.Float64()*2 - 1) * liveFeedJitter * float64(delay))
+		if err := sleep(ctx, delay+jitter); err != nil {
+			return
+		}
+	}
+}
+
+// backoffDelay returns the time to wait after the given number of consecutive failures, doubling interval with each
+// failure up to liveFeedMaxBackoff.
+func backoffDelay(interval time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 0; i < failures && delay < liveFeedMaxBackoff; i++ {
+		delay *= 2
+	}
+	return max(min(delay, liveFeedMaxBackoff), interval)
+}
+
+// setHealth sends a health transition, replacing one that was not received yet.
+func (f *LiveFeed) setHealth(h FeedHealth) {
+	select {
+	case <-f.health:
+	default:
+	}
+	f.health <- h
+}
+
+// poll fetches a snapshot and returns it if it is newer than the previous one, along with the error of the fetch.
+func (f *LiveFeed) poll(ctx context.Context) (*Response, error) {
+	res, err := getContext(ctx, f.api, &Request{Bbox: f.bbox})
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return nil, err
+	}
+	f.err = err
+	if err != nil || (f.snapshot != nil && res.Time <= f.snapshot.Time) {
+		return nil, err
+	}
+
+	res.States = latestStates(res.States)
+	f.snapshot = res
+	if f.tee != nil {
+		f.err = f.writeTee(res)
+	}
+	return res, nil
+}
+
+func (f *LiveFeed) writeTee(res *Response) error {
+	if err := f.format.write(f.tee, res); err != nil {
+		return err
+	}
+	if flusher, ok := f.tee.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// latestStates drops all but the most recent state of each aircraft, keeping the order of the states.
+func latestStates(states []*State) []*State {
+	latest := make(map[string]*State, len(states))
+	for _, s := range states {
+		if l, ok := latest[s.Icao24]; !ok || s.LastContact > l.LastContact {
+			latest[s.Icao24] = s
+		}
+	}
+	if len(latest) == len(states) {
+		return states
+	}
+
+	deduped := make([]*State, 0, len(latest))
+	for _, s := range states {
+		if latest[s.Icao24] == s {
+			deduped = append(deduped, s)
+		}
+	}
+	return deduped
+}
@@ -0,0 +1,165 @@
+package gopensky
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLiveFeed(t *testing.T) {
+	var mu sync.Mutex
+	var polls int
+	api := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+
+		// Every other poll returns the same snapshot again.
+		tick := 1545462880 + polls/2*10
+		return &Response{Time: tick, States: []*State{
+			{Icao24: "8076c4", LastContact: tick},
+			{Icao24: "8076c4", LastContact: tick - 5},
+		}}, nil
+	})
+
+	feed := NewLiveFeed(api, nil, time.Millisecond)
+
+	first := <-feed.Updates()
+	if len(first.Added) != 1 {
+		t.Fatalf("got first update %+v, want the aircraft added once", first)
+	}
+	second := <-feed.Updates()
+	if len(second.Added) != 0 || len(second.Updated) != 1 || second.Time <= first.Time {
+		t.Errorf("got second update %+v, want the aircraft updated in the next snapshot", second)
+	}
+
+	feed.Close()
+	if _, ok := <-feed.Updates(); ok {
+		t.Error("want the updates channel closed")
+	}
+	if s := feed.Snapshot(); s == nil || len(s.States) != 1 {
+		t.Errorf("got snapshot %+v, want one deduplicated state", s)
+	}
+}
+
+func TestLiveFeedCoalescesUpdates(t *testing.T) {
+	var mu sync.Mutex
+	var polls int
+	api := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+
+		// A new aircraft appears with every snapshot and the first one leaves after the second.
+		tick := 1545462880 + polls*10
+		states := []*State{{Icao24: "8076c4", LastContact: tick}}
+		if polls <= 2 {
+			states = append(states, &State{Icao24: "aa56da", LastContact: tick})
+		}
+		return &Response{Time: tick, States: states}, nil
+	})
+
+	feed := NewLiveFeed(api, nil, time.Millisecond)
+	defer feed.Close()
+
+	// Polling goes on while no update is received.
+	for {
+		mu.Lock()
+		n := polls
+		mu.Unlock()
+		if n >= 5 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	update := <-feed.Updates()
+	if len(update.Added) != 1 || update.Added[0].Icao24 != "8076c4" || len(update.Removed) != 0 || update.Time < 1545462880+50 {
+		t.Errorf("got update %+v, want the changes of all snapshots so far in one", update)
+	}
+}
+
+func TestLiveFeedTeeTo(t *testing.T) {
+	start := make(chan struct{})
+	var mu sync.Mutex
+	var polls int
+	api := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		<-start
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+
+		tick := 1545462880 + polls*10
+		return &Response{Time: tick, States: []*State{{Icao24: "8076c4", LastContact: tick, TimePosition: NoTimePosition}}}, nil
+	})
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	feed := NewLiveFeed(api, nil, time.Millisecond)
+	feed.TeeTo(w, FormatNDJSON)
+	close(start)
+
+	<-feed.Updates()
+	<-feed.Updates()
+	feed.Close()
+
+	if err := feed.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines < 2 || w.Buffered() != 0 {
+		t.Errorf("got %d lines and %d bytes buffered, want a flushed line per snapshot", lines, w.Buffered())
+	}
+}
+
+func TestLiveFeedHealth(t *testing.T) {
+	var mu sync.Mutex
+	var polls int
+	api := fakeApi(func(ctx context.Context, req *Request) (*Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+
+		if polls <= 2 {
+			return nil, errors.New("503 Service Unavailable")
+		}
+		return &Response{Time: 1545462880 + polls*10}, nil
+	})
+
+	feed := NewLiveFeed(api, nil, time.Millisecond)
+	defer feed.Close()
+
+	if h := <-feed.Health(); h != FeedDegraded {
+		t.Errorf("got %v, want degraded", h)
+	}
+	<-feed.Updates()
+	if h := <-feed.Health(); h != FeedHealthy {
+		t.Errorf("got %v, want healthy", h)
+	}
+	if err := feed.Err(); err != nil {
+		t.Errorf("got error %v after a successful poll", err)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{10 * time.Second, 0, 10 * time.Second},
+		{10 * time.Second, 3, 80 * time.Second},
+		{10 * time.Second, 100, liveFeedMaxBackoff},
+		{time.Hour, 2, time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := backoffDelay(test.interval, test.failures); got != test.want {
+			t.Errorf("%v after %d failures: got %v, want %v", test.interval, test.failures, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package gopensky
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures an Api created by New.
+type Option func(*api)
+
+// WithRetryOnEmpty makes Get request the states again, up to maxAttempts requests in total, while OpenSky returns no
+// states at all or an empty body (ErrEmptyBody). This smooths over brief gaps in the feed. Requests are spaced by
+// delay. Regions that are really empty still return an empty response once the attempts are used up.
+func WithRetryOnEmpty(maxAttempts int, delay time.Duration) Option {
+	return func(a *api) {
+		a.retryOnEmpty = maxAttempts
+		a.retryDelay = delay
+	}
+}
+
+// WithBackoffStrategy sets the wait before each retry, given the number of requests made so far. It replaces the fixed
+// delay of WithRetryOnEmpty, e.g. with a capped exponential or an exact schedule for tests.
+func WithBackoffStrategy(backoff func(attempt int) time.Duration) Option {
+	return func(a *api) {
+		a.backoff = backoff
+	}
+}
+
+// WithHeader adds a header to every request, e.g. for tracing or an API gateway. It can be given several times, also
+// for the same key. Credentials configured with WithClientCredentials or WithBasicAuth take precedence over an
+// Authorization header set this way.
+func WithHeader(key, value string) Option {
+	return func(a *api) {
+		if a.header == nil {
+			a.header = make(http.Header)
+		}
+		a.header.Add(key, value)
+	}
+}
+
+// WithCaptureHeaders makes Get keep the headers of the HTTP response in Response.Headers, e.g. to find out which
+// server answered a request.
+func WithCaptureHeaders() Option {
+	return func(a *api) {
+		a.captureHeaders = true
+	}
+}
+
+// WithLenientParsing makes Get keep state vectors with elements that cannot be parsed instead of failing. Such elements
+// are left at their zero value and described in State.ParseWarnings.
+func WithLenientParsing() Option {
+	return func(a *api) {
+		a.lenient = true
+	}
+}
+
+// WithDefaultRequest sets the request whose fields fill in those left zero or nil in each call's request, e.g. a
+// default Bbox for a service watching a single region. A nil request to Get uses the default as it is. Since false is
+// the zero value, a default Extended cannot be turned off per call other than through Extra.
+func WithDefaultRequest(req *Request) Option {
+	return func(a *api) {
+		a.defaults = req.Clone()
+	}
+}
+
+// withDefaults returns req with the zero fields set from the default request.
+func (a *api) withDefaults(req *Request) *Request {
+	if a.defaults == nil {
+		return req
+	}
+	if req == nil {
+		return a.defaults
+	}
+
+	merged := *req
+	if merged.Time == 0 {
+		merged.Time = a.defaults.Time
+	}
+	if merged.Icao24 == nil {
+		merged.Icao24 = a.defaults.Icao24
+	}
+	if merged.Bbox == nil {
+		merged.Bbox = a.defaults.Bbox
+	}
+	merged.Extended = merged.Extended || a.defaults.Extended
+	if merged.Extra == nil {
+		merged.Extra = a.defaults.Extra
+	}
+	return &merged
+}
+
+// Decoder unmarshals JSON like json.Unmarshal. The configurations of jsoniter and sonic implement it.
+type Decoder interface {
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// WithDecoder makes Get decode responses with d instead of encoding/json, e.g. a faster third-party decoder for
+// responses with all states worldwide. It must decode JSON numbers into an interface{} as float64, as json.Unmarshal
+// does. Unlike encoding/json, which streams the body, d is given the whole body at once, and a truncated body is only
+// reported as ErrTruncatedResponse if d returns io.ErrUnexpectedEOF.
+func WithDecoder(d Decoder) Option {
+	return func(a *api) {
+		a.decoder = d
+	}
+}
+
+// WithMaxConcurrency limits the requests in flight at once to n, however many goroutines share the Api. Further
+// requests wait, as long as their context allows, until one finishes. A request is in flight until its response has
+// been read, but not while waiting between retries. An n less than 1 means no limit.
+func WithMaxConcurrency(n int) Option {
+	return func(a *api) {
+		a.inFlight = nil
+		if n > 0 {
+			a.inFlight = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithTimeToleranceCheck makes Get fail with ErrTimeMismatch when states are requested for a time and OpenSky serves
+// them for a time more than max away, e.g. because it snapped to the nearest snapshot it has. Requests without a
+// time are not checked.
+func WithTimeToleranceCheck(max time.Duration) Option {
+	return func(a *api) {
+		a.timeTolerance = max
+	}
+}
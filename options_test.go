@@ -0,0 +1,301 @@
+package gopensky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newSequenceClient returns an http.Client that answers requests with the given bodies in turn, repeating the last
+// one, and counts the requests it served.
+func newSequenceClient(calls *int, bodies ...string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := bodies[min(*calls, len(bodies)-1)]
+		*calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+// newQueryClient returns an http.Client that answers every request with body and records the query of the latest one.
+func newQueryClient(query *string, body string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		*query = req.URL.RawQuery
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+const (
+	emptyBody = `{"time":1545462880,"states":[]}`
+	stateBody = `{"time":1545462890,"states":[["8076c4","JAI824  ","India",1545462879,1545462879,77.4861,28.4392,1744.98,false,110.61,316.13,-3.58,null,1767.84,"2701",false,0]]}`
+)
+
+func TestRetryOnEmpty(t *testing.T) {
+	var calls int
+	api := New(newSequenceClient(&calls, emptyBody, emptyBody, stateBody), WithRetryOnEmpty(5, time.Millisecond))
+
+	res, err := api.Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || len(res.States) != 1 {
+		t.Errorf("got %d states after %d requests, want 1 state after 3 requests", len(res.States), calls)
+	}
+}
+
+func TestRetryOnEmptyGivesUp(t *testing.T) {
+	var calls int
+	api := New(newSequenceClient(&calls, emptyBody), WithRetryOnEmpty(3, time.Millisecond))
+
+	res, err := api.Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || len(res.States) != 0 {
+		t.Errorf("got %d states after %d requests, want 0 states after 3 requests", len(res.States), calls)
+	}
+}
+
+func TestRetryOnEmptyCancelled(t *testing.T) {
+	var calls int
+	api := New(newSequenceClient(&calls, emptyBody), WithRetryOnEmpty(3, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := getContext(ctx, api, nil); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestBackoffStrategy(t *testing.T) {
+	var calls int
+	var attempts []int
+	api := New(newSequenceClient(&calls, emptyBody), WithRetryOnEmpty(3, time.Hour), WithBackoffStrategy(func(attempt int) time.Duration {
+		attempts = append(attempts, attempt)
+		return time.Millisecond
+	}))
+
+	if _, err := api.Get(nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("got backoff for attempts %v, want [1 2]", attempts)
+	}
+}
+
+func TestCaptureHeaders(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Date": {"Sat, 22 Dec 2018 07:14:40 GMT"}},
+			Body:       io.NopCloser(strings.NewReader(stateBody)),
+			Request:    req,
+		}, nil
+	})}
+
+	res, err := New(client).Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Headers != nil {
+		t.Errorf("got headers %v without WithCaptureHeaders", res.Headers)
+	}
+
+	res, err = New(client, WithCaptureHeaders()).Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Headers.Get("Date"); got != "Sat, 22 Dec 2018 07:14:40 GMT" {
+		t.Errorf("got Date header %q", got)
+	}
+}
+
+func TestWithHeader(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if got := req.Header.Values("X-Custom"); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+			t.Errorf("got X-Custom %v, want [a b]", got)
+		}
+		if got := req.Header.Get("Traceparent"); got != "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01" {
+			t.Errorf("got traceparent %q", got)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(stateBody)), Request: req}, nil
+	})}
+
+	api := New(client, WithHeader("X-Custom", "a"), WithHeader("X-Custom", "b"), WithHeader("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"))
+	if _, err := api.Get(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultRequest(t *testing.T) {
+	var query string
+	client := newQueryClient(&query, stateBody)
+	defaults := &Request{Bbox: &Bbox{Lamin: 45.8389, Lomin: 5.9962, Lamax: 47.8229, Lomax: 10.5226}, Extended: true}
+	api := New(client, WithDefaultRequest(defaults))
+	defaults.Bbox.Lamin = 0
+
+	tests := []struct {
+		req  *Request
+		want string
+	}{
+		{nil, "extended=1&lamax=47.8229&lamin=45.8389&lomax=10.5226&lomin=5.9962"},
+		{&Request{Icao24: []string{"8076c4"}}, "extended=1&icao24=8076c4&lamax=47.8229&lamin=45.8389&lomax=10.5226&lomin=5.9962"},
+		{&Request{Bbox: &Bbox{Lamin: 1, Lomin: 2, Lamax: 3, Lomax: 4}}, "extended=1&lamax=3.0000&lamin=1.0000&lomax=4.0000&lomin=2.0000"},
+	}
+	for _, tt := range tests {
+		if _, err := api.Get(tt.req); err != nil {
+			t.Fatal(err)
+		}
+		if query != tt.want {
+			t.Errorf("got query %q for %+v, want %q", query, tt.req, tt.want)
+		}
+	}
+}
+
+// unmarshalDecoder is a Decoder that counts its calls to json.Unmarshal.
+type unmarshalDecoder struct {
+	calls int
+}
+
+func (d *unmarshalDecoder) Unmarshal(data []byte, v interface{}) error {
+	d.calls++
+	return json.Unmarshal(data, v)
+}
+
+func TestWithDecoder(t *testing.T) {
+	var calls int
+	d := &unmarshalDecoder{}
+	res, err := New(newSequenceClient(&calls, stateBody), WithDecoder(d)).Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.calls != 1 || len(res.States) != 1 || res.States[0].Icao24 != "8076c4" {
+		t.Errorf("got %d decoder calls and states %v", d.calls, res.States)
+	}
+}
+
+func BenchmarkGetSample(b *testing.B) {
+	client := newSampleClient(b)
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"stream", nil},
+		{"decoder", []Option{WithDecoder(&unmarshalDecoder{})}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			api := New(client, bench.opts...)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := api.Get(nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestEmptyBody(t *testing.T) {
+	var calls int
+	if _, err := New(newSequenceClient(&calls, "")).Get(nil); !errors.Is(err, ErrEmptyBody) {
+		t.Errorf("got error %v, want %v", err, ErrEmptyBody)
+	}
+	if _, err := New(newSequenceClient(&calls, "\n"), WithDecoder(&unmarshalDecoder{})).Get(nil); !errors.Is(err, ErrEmptyBody) {
+		t.Errorf("got error %v with a decoder, want %v", err, ErrEmptyBody)
+	}
+
+	calls = 0
+	res, err := New(newSequenceClient(&calls, "", stateBody), WithRetryOnEmpty(3, time.Millisecond)).Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || len(res.States) != 1 || res.CreditsUsed != 4 {
+		t.Errorf("got %d requests, %d states and %d credits", calls, len(res.States), res.CreditsUsed)
+	}
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, peak int
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(stateBody)), Request: req}, nil
+	})}
+	api := New(client, WithMaxConcurrency(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := api.Get(nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("got %d requests in flight at once, want at most 2", peak)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	blocked := New(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		close(started)
+		select {
+		case <-release:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(stateBody)), Request: req}, nil
+	})}, WithMaxConcurrency(1))
+	go blocked.Get(nil)
+	defer close(release)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := getContext(ctx, blocked, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the context error while waiting", err)
+	}
+}
+
+func TestTimeToleranceCheck(t *testing.T) {
+	var calls int
+	api := New(newSequenceClient(&calls, stateBody), WithTimeToleranceCheck(5*time.Second))
+
+	if _, err := api.Get(&Request{Time: 1545462880}); !errors.Is(err, ErrTimeMismatch) || !strings.Contains(err.Error(), "1545462890") {
+		t.Errorf("got error %v, want %v with both times", err, ErrTimeMismatch)
+	}
+	if _, err := api.Get(&Request{Time: 1545462887}); err != nil {
+		t.Errorf("got error %v within the tolerance", err)
+	}
+	if _, err := api.Get(nil); err != nil {
+		t.Errorf("got error %v without a requested time", err)
+	}
+}
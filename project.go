@@ -0,0 +1,125 @@
+package gopensky
+
+import (
+	"math"
+	"strconv"
+)
+
+// StateField identifies an element of a state vector, in the order of the vector.
+type StateField int
+
+const (
+	FieldIcao24 StateField = iota
+	FieldCallsign
+	FieldOriginCountry
+	FieldTimePosition
+	FieldLastContact
+	FieldLongitude
+	FieldLatitude
+	FieldBaroAltitude
+	FieldOnGround
+	FieldVelocity
+	FieldTrueTrack
+	FieldVerticalRate
+	FieldSensors
+	FieldGeoAltitude
+	FieldSquawk
+	FieldSpi
+	FieldPositionSource
+	FieldCategory
+)
+
+// String returns the name of the field as documented by OpenSky, e.g. "baro_altitude".
+func (f StateField) String() string {
+	if f < 0 || int(f) >= len(stateFields) {
+		return "StateField(" + strconv.Itoa(int(f)) + ")"
+	}
+	return stateFields[f]
+}
+
+// Project returns a copy of the response whose states keep only the given fields, e.g. to hold many snapshots in memory
+// for aggregation. The other fields are reset to null, which is NoTimePosition for TimePosition, so callsign strings,
+// sensor slices and parse warnings can be reclaimed once the original response is dropped. TimePosition is kept with
+// either coordinate, as it tells whether the state has a position.
+func (r *Response) Project(fields ...StateField) *Response {
+	keep := make([]bool, len(stateFields))
+	for _, f := range fields {
+		if f >= 0 && int(f) < len(keep) {
+			keep[f] = true
+		}
+	}
+	if keep[FieldLatitude] || keep[FieldLongitude] {
+		keep[FieldTimePosition] = true
+	}
+
+	projected := *r
+	projected.States = make([]*State, len(r.States))
+	for i, s := range r.States {
+		p := &State{TimePosition: NoTimePosition}
+		for f, ok := range keep {
+			if ok {
+				copyField(p, s, StateField(f))
+			}
+		}
+		projected.States[i] = p
+	}
+	return &projected
+}
+
+func copyField(dst, src *State, f StateField) {
+	switch f {
+	case FieldIcao24:
+		dst.Icao24 = src.Icao24
+	case FieldCallsign:
+		dst.Callsign = src.Callsign
+	case FieldOriginCountry:
+		dst.OriginCountry = src.OriginCountry
+	case FieldTimePosition:
+		dst.TimePosition = src.TimePosition
+	case FieldLastContact:
+		dst.LastContact = src.LastContact
+	case FieldLongitude:
+		dst.Longitude = src.Longitude
+	case FieldLatitude:
+		dst.Latitude = src.Latitude
+	case FieldBaroAltitude:
+		dst.BaroAltitude = src.BaroAltitude
+	case FieldOnGround:
+		dst.OnGround = src.OnGround
+	case FieldVelocity:
+		dst.Velocity = src.Velocity
+	case FieldTrueTrack:
+		dst.TrueTrack = src.TrueTrack
+	case FieldVerticalRate:
+		dst.VerticalRate = src.VerticalRate
+	case FieldSensors:
+		dst.Sensors = src.Sensors
+	case FieldGeoAltitude:
+		dst.GeoAltitude = src.GeoAltitude
+	case FieldSquawk:
+		dst.Squawk = src.Squawk
+	case FieldSpi:
+		dst.Spi = src.Spi
+	case FieldPositionSource:
+		dst.PositionSource = src.PositionSource
+	case FieldCategory:
+		dst.Category = src.Category
+	}
+}
+
+// Quantize returns a copy of the response with the positions snapped to the nearest multiple of gridDegrees, e.g. to
+// aggregate traffic or to publish it without exact positions. States without a position are copied as they are, and
+// so are all states if gridDegrees is not positive.
+func (r *Response) Quantize(gridDegrees float64) *Response {
+	quantized := *r
+	quantized.States = make([]*State, len(r.States))
+	for i, s := range r.States {
+		q := *s
+		if q.HasPosition() && gridDegrees > 0 {
+			q.Latitude = math.Round(q.Latitude/gridDegrees) * gridDegrees
+			q.Longitude = math.Round(q.Longitude/gridDegrees) * gridDegrees
+		}
+		quantized.States[i] = &q
+	}
+	return &quantized
+}
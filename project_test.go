@@ -0,0 +1,51 @@
+package gopensky
+
+import "testing"
+
+func TestProject(t *testing.T) {
+	res := &Response{Time: 1545462890, States: []*State{{
+		Icao24: "8076c4", Callsign: "JAI824  ", TimePosition: 1545462879, Longitude: 77.4861, Latitude: 28.4392,
+		BaroAltitude: 1744.98, Sensors: []int{1}, Squawk: "2701",
+	}}}
+
+	p := res.Project(FieldIcao24, FieldLongitude, FieldLatitude, FieldTimePosition, FieldBaroAltitude)
+	s := p.States[0]
+	if p.Time != res.Time || s.Icao24 != "8076c4" || !s.HasPosition() || s.Latitude != 28.4392 || s.BaroAltitude != 1744.98 {
+		t.Errorf("got %+v, want the projected fields kept", s)
+	}
+	if s.Callsign != "" || s.Sensors != nil || s.Squawk != "" {
+		t.Errorf("got %+v, want the other fields reset", s)
+	}
+	if res.States[0].Callsign != "JAI824  " {
+		t.Error("want the original response unchanged")
+	}
+
+	if s := res.Project(FieldIcao24, FieldLatitude, FieldLongitude, FieldBaroAltitude).States[0]; !s.HasPosition() || s.TimePosition != 1545462879 {
+		t.Errorf("got %+v, want the position kept without projecting the time position", s)
+	}
+	if s := res.Project(FieldIcao24).States[0]; s.HasPosition() {
+		t.Errorf("got time position %d without projecting it", s.TimePosition)
+	}
+	if FieldBaroAltitude.String() != "baro_altitude" {
+		t.Errorf("got %q", FieldBaroAltitude)
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	res := &Response{States: []*State{{TimePosition: 1545462879, Latitude: 28.4392, Longitude: 77.4861}, {TimePosition: NoTimePosition}}}
+
+	q := res.Quantize(0.5)
+	if s := q.States[0]; s.Latitude != 28.5 || s.Longitude != 77.5 {
+		t.Errorf("got %g, %g, want 28.5, 77.5", s.Latitude, s.Longitude)
+	}
+	if q.States[1].HasPosition() || res.States[0].Latitude != 28.4392 {
+		t.Errorf("got %+v, want only positions quantized and the original unchanged", q.States)
+	}
+
+	for _, grid := range []float64{0, -1} {
+		q := res.Quantize(grid)
+		if s := q.States[0]; s.Latitude != 28.4392 || s.Longitude != 77.4861 || s == res.States[0] {
+			t.Errorf("got %+v for grid %g, want an unchanged copy", s, grid)
+		}
+	}
+}
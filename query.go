@@ -0,0 +1,94 @@
+package gopensky
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Query builds a Request step by step and validates it once it is complete:
+//
+//	req, err := gopensky.NewQuery().ICAO24("abc9f3").BBox(bbox).Extended().Build()
+//
+// The zero value is an empty query.
+type Query struct {
+	req Request
+}
+
+// NewQuery returns an empty query, which builds a request for the current states of all aircraft.
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// Time requests the states at t instead of the current ones.
+func (q *Query) Time(t time.Time) *Query {
+	q.req.Time = int(t.Unix())
+	return q
+}
+
+// ICAO24 restricts the query to the given transponder addresses, in addition to any added before.
+func (q *Query) ICAO24(icao24 ...string) *Query {
+	q.req.Icao24 = append(q.req.Icao24, icao24...)
+	return q
+}
+
+// BBox restricts the query to the states within b.
+func (q *Query) BBox(b *Bbox) *Query {
+	q.req.Bbox = b
+	return q
+}
+
+// Extended requests the aircraft category of each state.
+func (q *Query) Extended() *Query {
+	q.req.Extended = true
+	return q
+}
+
+// Build validates the query and returns the request for it, which can be passed to Get. It reports every problem
+// found: ICAO24 addresses that are not 6 hex digits, an invalid bounding box and a time in the future or further in the
+// past than MaxStatesHistory. Addresses are lower cased, as OpenSky expects. The request does not share memory with
+// the query, which can be reused.
+func (q *Query) Build() (*Request, error) {
+	var errs []error
+	req := q.req.Clone()
+
+	for i, icao24 := range req.Icao24 {
+		if !isICAO24(icao24) {
+			errs = append(errs, fmt.Errorf("icao24 %q is not 6 hex digits", icao24))
+		}
+		req.Icao24[i] = strings.ToLower(icao24)
+	}
+	if req.Bbox != nil {
+		if err := req.Bbox.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if req.Time != 0 {
+		switch age := time.Since(time.Unix(int64(req.Time), 0)); {
+		case age < -maxFutureSkew:
+			errs = append(errs, fmt.Errorf("time %d is %s in the future", req.Time, (-age).Round(time.Second)))
+		case age > MaxStatesHistory:
+			errs = append(errs, fmt.Errorf("time %d is %s in the past, more than the %s OpenSky serves", req.Time,
+				age.Round(time.Second), MaxStatesHistory))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// isICAO24 reports whether s is a 24-bit address as 6 hex digits.
+func isICAO24(s string) bool {
+	if len(s) != 6 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package gopensky
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryBuild(t *testing.T) {
+	at := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	bbox := &Bbox{Lamin: 45.8389, Lomin: 5.9962, Lamax: 47.8229, Lomax: 10.5226}
+	q := NewQuery().Time(at).ICAO24("ABC9F3").BBox(bbox).Extended()
+
+	req, err := q.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Time != int(at.Unix()) || len(req.Icao24) != 1 || req.Icao24[0] != "abc9f3" || *req.Bbox != *bbox || !req.Extended {
+		t.Errorf("got request %+v", req)
+	}
+	if q.req.Icao24[0] != "ABC9F3" || req.Bbox == bbox {
+		t.Errorf("request shares memory with the query")
+	}
+}
+
+func TestQueryBuildInvalid(t *testing.T) {
+	_, err := NewQuery().
+		ICAO24("abc9f3", "abc9f", "xyz123").
+		BBox(&Bbox{Lamin: 10, Lomin: 0, Lamax: -10, Lomax: 10}).
+		Time(time.Now().Add(-2 * time.Hour)).
+		Build()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	for _, want := range []string{`"abc9f"`, `"xyz123"`, "lamin", "in the past"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got error %q, want it to mention %s", err, want)
+		}
+	}
+
+	if _, err := NewQuery().Time(time.Now().Add(time.Hour)).Build(); err == nil || !strings.Contains(err.Error(), "future") {
+		t.Errorf("got error %v for a time in the future", err)
+	}
+}
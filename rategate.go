@@ -0,0 +1,80 @@
+package gopensky
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RateGate throttles per-aircraft updates pushed to a consumer such as a live map. An update passes if the aircraft
+// moved or changed altitude noticeably, or if it has not been updated for MaxInterval, but never sooner than
+// MinInterval after the previous update. The zero value lets every update through and is ready to use.
+type RateGate struct {
+	// Minimum time between two updates of the same aircraft.
+	MinInterval time.Duration
+	// Time after which an aircraft is updated even if it did not change. Zero means never.
+	MaxInterval time.Duration
+	// Change in position and altitude in meters that counts as noticeable. If both are zero, every update counts as a
+	// change, so only MinInterval throttles updates.
+	MinDistance       float64
+	MinAltitudeChange float64
+
+	now func() time.Time
+
+	mu   sync.Mutex
+	last map[string]gatedState
+}
+
+type gatedState struct {
+	at       time.Time
+	position Point
+	altitude float64
+}
+
+// Allow reports whether an update to s should be passed on, and if so remembers it as the aircraft's latest update.
+func (g *RateGate) Allow(s *State) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if g.now != nil {
+		now = g.now()
+	}
+	if g.last == nil {
+		g.last = make(map[string]gatedState)
+	}
+
+	current := gatedState{at: now, position: Point{Latitude: s.Latitude, Longitude: s.Longitude}, altitude: s.BaroAltitude}
+	prev, ok := g.last[s.Icao24]
+	if ok && !g.due(prev, current) {
+		return false
+	}
+
+	g.last[s.Icao24] = current
+	return true
+}
+
+func (g *RateGate) due(prev, current gatedState) bool {
+	elapsed := current.at.Sub(prev.at)
+	switch {
+	case elapsed < g.MinInterval:
+		return false
+	case g.MaxInterval > 0 && elapsed >= g.MaxInterval:
+		return true
+	case g.MinDistance == 0 && g.MinAltitudeChange == 0:
+		return true
+	}
+
+	moved := distanceKm(prev.position, current.position) * 1000
+	climbed := math.Abs(current.altitude - prev.altitude)
+	return moved > g.MinDistance || climbed > g.MinAltitudeChange
+}
+
+// Forget drops what the gate remembers about an aircraft, e.g. once it left the area of interest, so that its next
+// update passes.
+func (g *RateGate) Forget(icao24 string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.last, icao24)
+}
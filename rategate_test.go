@@ -0,0 +1,54 @@
+package gopensky
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateGate(t *testing.T) {
+	now := time.Unix(1545462880, 0)
+	g := &RateGate{MinInterval: time.Second, MaxInterval: 30 * time.Second, MinDistance: 100, MinAltitudeChange: 30, now: func() time.Time { return now }}
+	s := &State{Icao24: "8076c4", Latitude: 28.4392, Longitude: 77.4861, BaroAltitude: 1744.98}
+
+	if !g.Allow(s) {
+		t.Error("want the first update to pass")
+	}
+
+	// Moved about 1.1 km, but too soon.
+	now = now.Add(500 * time.Millisecond)
+	moved := *s
+	moved.Latitude += 0.01
+	if g.Allow(&moved) {
+		t.Error("want an update within MinInterval to be suppressed")
+	}
+
+	now = now.Add(time.Second)
+	if !g.Allow(&moved) {
+		t.Error("want a noticeable move to pass")
+	}
+
+	now = now.Add(5 * time.Second)
+	if g.Allow(&moved) {
+		t.Error("want an unchanged aircraft to be suppressed")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !g.Allow(&moved) {
+		t.Error("want an unchanged aircraft to pass after MaxInterval")
+	}
+
+	g.Forget(s.Icao24)
+	if !g.Allow(&moved) {
+		t.Error("want a forgotten aircraft to pass")
+	}
+}
+
+func TestRateGateZeroValue(t *testing.T) {
+	var g RateGate
+	s := &State{Icao24: "8076c4", Latitude: 28.4392, Longitude: 77.4861, BaroAltitude: 1744.98}
+	for i := 0; i < 3; i++ {
+		if !g.Allow(s) {
+			t.Errorf("update %d: want every update of an unchanged aircraft to pass", i)
+		}
+	}
+}
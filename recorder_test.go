@@ -0,0 +1,53 @@
+package gopensky
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"time":%d,"states":[]}`, 1545462880+calls)
+	}))
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+
+	get := func(client *http.Client) string {
+		res, err := client.Get(server.URL + "/api/states/all")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	client, err := NewRecorder(server.Client(), cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := get(client), get(client)
+	server.Close()
+
+	client, err = NewRecorder(&http.Client{}, cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := get(client); got != first {
+		t.Errorf("got %s, want the first recorded response %s", got, first)
+	}
+	if got := get(client); got != second {
+		t.Errorf("got %s, want the second recorded response %s", got, second)
+	}
+	if calls != 2 {
+		t.Errorf("got %d requests to the server, want 2", calls)
+	}
+}
@@ -0,0 +1,353 @@
+package gopensky
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Interval returns the observation window [Time−1, Time] that the state vectors in the response represent.
+func (r *Response) Interval() (start, end time.Time) {
+	end = time.Unix(int64(r.Time), 0)
+	return end.Add(-time.Second), end
+}
+
+// ServerSkew estimates how far the server clock is ahead of clientNow, the local time at which the response was
+// received. It uses the Date header if the response was fetched with WithCaptureHeaders, and otherwise Time, which
+// trails the server clock by up to the time resolution of the states, so small skews are not meaningful then.
+func (r *Response) ServerSkew(clientNow time.Time) time.Duration {
+	if date, err := http.ParseTime(r.Headers.Get("Date")); err == nil {
+		return date.Sub(clientNow)
+	}
+	return time.Unix(int64(r.Time), 0).Sub(clientNow)
+}
+
+// Pages yields the states in successive slices of up to size states. It panics if size is less than 1.
+func (r *Response) Pages(size int) iter.Seq[[]*State] {
+	return slices.Chunk(r.States, size)
+}
+
+// OnGround returns the states of all vehicles on the ground.
+func (r *Response) OnGround() []*State {
+	return r.filter(func(s *State) bool { return s.OnGround })
+}
+
+// Airborne returns the states of all vehicles in the air.
+func (r *Response) Airborne() []*State {
+	return r.filter(func(s *State) bool { return !s.OnGround })
+}
+
+// WithCallsign returns the states that carry a callsign. Callsigns are padded with spaces, so a callsign consisting only
+// of spaces counts as missing.
+func (r *Response) WithCallsign() []*State {
+	return r.filter(func(s *State) bool { return trimCallsign(s.Callsign) != "" })
+}
+
+// WithinBbox returns the states positioned within b, e.g. the visible part of a larger box that was fetched. States
+// without a position are left out.
+func (r *Response) WithinBbox(b *Bbox) []*State {
+	return r.filter(func(s *State) bool { return s.HasPosition() && b.Contains(s.Latitude, s.Longitude) })
+}
+
+// GroupByCountry groups the states by OriginCountry. States without an origin country are grouped under "".
+func (r *Response) GroupByCountry() map[string][]*State {
+	groups := make(map[string][]*State)
+	for _, s := range r.States {
+		groups[s.OriginCountry] = append(groups[s.OriginCountry], s)
+	}
+	return groups
+}
+
+// CountryCount is the number of aircraft from an origin country.
+type CountryCount struct {
+	Country string
+	Count   int
+}
+
+// CountryLeaderboard returns the number of aircraft per origin country, the busiest first and ties in alphabetical
+// order. Aircraft without an origin country are counted under "".
+func (r *Response) CountryLeaderboard() []CountryCount {
+	groups := r.GroupByCountry()
+	leaderboard := make([]CountryCount, 0, len(groups))
+	for country, states := range groups {
+		leaderboard = append(leaderboard, CountryCount{Country: country, Count: len(states)})
+	}
+	slices.SortFunc(leaderboard, func(a, b CountryCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Country, b.Country)
+	})
+	return leaderboard
+}
+
+// CallsignIndex maps the callsigns in the response, trimmed of padding, to the ICAO24 address of the aircraft using
+// them. If several aircraft report the same callsign, the one with the latest LastContact wins. States without a
+// callsign are left out.
+func (r *Response) CallsignIndex() map[string]string {
+	latest := make(map[string]*State)
+	for _, s := range r.States {
+		callsign := trimCallsign(s.Callsign)
+		if callsign == "" {
+			continue
+		}
+		if l, ok := latest[callsign]; !ok || s.LastContact > l.LastContact {
+			latest[callsign] = s
+		}
+	}
+
+	index := make(map[string]string, len(latest))
+	for callsign, s := range latest {
+		index[callsign] = s.Icao24
+	}
+	return index
+}
+
+// MergeSensors returns, per ICAO24 address, the sorted IDs of all receivers that contributed to any of the states in
+// the responses, e.g. several /states/own snapshots. Aircraft without sensor information are left out.
+func MergeSensors(responses ...*Response) map[string][]int {
+	sets := make(map[string]map[int]bool)
+	for _, r := range responses {
+		for _, s := range r.States {
+			if len(s.Sensors) == 0 {
+				continue
+			}
+			if sets[s.Icao24] == nil {
+				sets[s.Icao24] = make(map[int]bool)
+			}
+			for _, sensor := range s.Sensors {
+				sets[s.Icao24][sensor] = true
+			}
+		}
+	}
+
+	merged := make(map[string][]int, len(sets))
+	for icao24, set := range sets {
+		sensors := make([]int, 0, len(set))
+		for sensor := range set {
+			sensors = append(sensors, sensor)
+		}
+		slices.Sort(sensors)
+		merged[icao24] = sensors
+	}
+	return merged
+}
+
+// DefaultSourcePriority ranks the position sources by accuracy: ADS-B, then MLAT, then ASTERIX.
+var DefaultSourcePriority = []PositionSource{SourceADSB, SourceMLAT, SourceASTERIX}
+
+// Dedupe returns one state per aircraft, in the order in which the aircraft first appear, for responses in which an
+// aircraft was reported more than once, e.g. from several sources. States with a position are preferred, then those
+// whose position source comes first in priority, or DefaultSourcePriority if priority is nil, and then the most recent
+// ones. Sources missing from priority rank last.
+func (r *Response) Dedupe(priority []PositionSource) []*State {
+	if priority == nil {
+		priority = DefaultSourcePriority
+	}
+
+	best := make(map[string]*State, len(r.States))
+	order := make([]string, 0, len(r.States))
+	for _, s := range r.States {
+		b, ok := best[s.Icao24]
+		if !ok {
+			order = append(order, s.Icao24)
+		}
+		if !ok || preferred(s, b, priority) {
+			best[s.Icao24] = s
+		}
+	}
+
+	states := make([]*State, len(order))
+	for i, icao24 := range order {
+		states[i] = best[icao24]
+	}
+	return states
+}
+
+// ByICAO24 returns the states keyed by ICAO24 address, resolving aircraft reported more than once like Dedupe with
+// DefaultSourcePriority.
+func (r *Response) ByICAO24() map[string]*State {
+	states := r.Dedupe(nil)
+	byICAO24 := make(map[string]*State, len(states))
+	for _, s := range states {
+		byICAO24[s.Icao24] = s
+	}
+	return byICAO24
+}
+
+// preferred reports whether s is preferred over other when deduplicating.
+func preferred(s, other *State, priority []PositionSource) bool {
+	if s.HasPosition() != other.HasPosition() {
+		return s.HasPosition()
+	}
+	if rank, otherRank := sourceRank(s.PositionSource, priority), sourceRank(other.PositionSource, priority); rank != otherRank {
+		return rank < otherRank
+	}
+	return s.LastContact > other.LastContact
+}
+
+func sourceRank(source PositionSource, priority []PositionSource) int {
+	if i := slices.Index(priority, source); i >= 0 {
+		return i
+	}
+	return len(priority)
+}
+
+func trimCallsign(callsign string) string {
+	return strings.TrimSpace(callsign)
+}
+
+func (r *Response) filter(keep func(*State) bool) []*State {
+	states := make([]*State, 0)
+	for _, s := range r.States {
+		if keep(s) {
+			states = append(states, s)
+		}
+	}
+	return states
+}
+
+// ResponseStats are aggregate counts over the states of a response.
+type ResponseStats struct {
+	Total      int
+	Airborne   int
+	OnGround   int
+	NoPosition int
+	// Number of states per PositionSource.
+	BySource map[PositionSource]int
+	// Number of states per OriginCountry.
+	ByCountry map[string]int
+}
+
+// Stats counts the states of the response in a single pass.
+func (r *Response) Stats() *ResponseStats {
+	stats := &ResponseStats{
+		Total:     len(r.States),
+		BySource:  make(map[PositionSource]int),
+		ByCountry: make(map[string]int),
+	}
+
+	for _, s := range r.States {
+		if s.OnGround {
+			stats.OnGround++
+		} else {
+			stats.Airborne++
+		}
+		if !s.HasPosition() {
+			stats.NoPosition++
+		}
+		stats.BySource[s.PositionSource]++
+		stats.ByCountry[s.OriginCountry]++
+	}
+
+	return stats
+}
+
+// SanityLimits are the bounds outside of which a state is considered bogus.
+type SanityLimits struct {
+	// Range of plausible barometric and geometric altitudes in meters.
+	MinAltitude float64
+	MaxAltitude float64
+	// Highest plausible velocity over ground in m/s.
+	MaxVelocity float64
+	// Accept positions at exactly 0,0, which are usually a receiver or decoder defaulting the coordinates.
+	AllowNullIsland bool
+}
+
+// DefaultSanityLimits are the limits used by DropBogus.
+var DefaultSanityLimits = SanityLimits{
+	MinAltitude: -500,
+	MaxAltitude: 20000,
+	MaxVelocity: 400,
+}
+
+// DropBogus returns the states within DefaultSanityLimits and the number of states dropped.
+func (r *Response) DropBogus() (kept []*State, dropped int) {
+	return r.DropBogusWith(DefaultSanityLimits)
+}
+
+// DropBogusWith returns the states within the given limits and the number of states dropped.
+func (r *Response) DropBogusWith(limits SanityLimits) (kept []*State, dropped int) {
+	kept = r.filter(limits.plausible)
+	return kept, len(r.States) - len(kept)
+}
+
+func (l SanityLimits) plausible(s *State) bool {
+	switch {
+	case s.HasPosition() && s.Latitude == 0 && s.Longitude == 0 && !l.AllowNullIsland:
+		return false
+	case s.BaroAltitude < l.MinAltitude || s.BaroAltitude > l.MaxAltitude:
+		return false
+	case s.GeoAltitude < l.MinAltitude || s.GeoAltitude > l.MaxAltitude:
+		return false
+	case s.Velocity < 0 || s.Velocity > l.MaxVelocity:
+		return false
+	}
+	return true
+}
+
+// How far ahead of the local clock Validate accepts the time of a response, to allow for clock skew.
+const maxFutureSkew = 10 * time.Minute
+
+// Validate checks that the response is self-consistent: its time is positive and not ahead of the local clock by more
+// than a few minutes, and every state has an ICAO24 address and a known position source. It returns all violations
+// joined into one error, or nil.
+func (r *Response) Validate() error {
+	var errs []error
+	if r.Time <= 0 {
+		errs = append(errs, fmt.Errorf("time %d is not positive", r.Time))
+	} else if ahead := time.Until(time.Unix(int64(r.Time), 0)); ahead > maxFutureSkew {
+		errs = append(errs, fmt.Errorf("time %d is %s in the future", r.Time, ahead.Round(time.Second)))
+	}
+
+	for i, s := range r.States {
+		if s.Icao24 == "" {
+			errs = append(errs, fmt.Errorf("state %d: empty icao24", i))
+		}
+		if s.PositionSource < SourceADSB || s.PositionSource > SourceMLAT {
+			errs = append(errs, fmt.Errorf("state %d: unknown position source %d", i, s.PositionSource))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AltitudeHistogram counts the airborne aircraft per altitude band of bandMeters, keyed by band index: band i covers
+// [i*bandMeters, (i+1)*bandMeters). It uses BestAltitude, so aircraft without an altitude are not counted. The
+// histogram is empty if bandMeters is not positive.
+func (r *Response) AltitudeHistogram(bandMeters float64) map[int]int {
+	histogram := make(map[int]int)
+	if bandMeters <= 0 {
+		return histogram
+	}
+	for _, s := range r.States {
+		if meters, _, ok := s.BestAltitude(); ok && !s.OnGround {
+			histogram[int(math.Floor(meters/bandMeters))]++
+		}
+	}
+	return histogram
+}
+
+// Length of an international foot, in which flight levels are defined.
+const metersPerFoot = 0.3048
+
+// FlightLevelHistogram counts the airborne aircraft per band of bandLevels flight levels, keyed by band index: with
+// bands of 10, band 35 covers FL350 to FL359. Flight levels are pressure altitudes, so aircraft without a barometric
+// altitude are not counted. The histogram is empty if bandLevels is not positive.
+func (r *Response) FlightLevelHistogram(bandLevels int) map[int]int {
+	histogram := make(map[int]int)
+	if bandLevels <= 0 {
+		return histogram
+	}
+	for _, s := range r.States {
+		if s.BaroAltitude != 0 && !s.OnGround {
+			level := math.Round(s.BaroAltitude/metersPerFoot) / 100
+			histogram[int(math.Floor(level/float64(bandLevels)))]++
+		}
+	}
+	return histogram
+}
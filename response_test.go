@@ -0,0 +1,215 @@
+package gopensky
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWithCallsign(t *testing.T) {
+	res := &Response{States: []*State{{Icao24: "8076c4", Callsign: "JAI824  "}, {Icao24: "aa56da", Callsign: "        "}, {Icao24: "7c6b2f"}}}
+
+	states := res.WithCallsign()
+	if len(states) != 1 || states[0].Icao24 != "8076c4" {
+		t.Errorf("got %v, want only 8076c4", states)
+	}
+}
+
+func TestWithinBbox(t *testing.T) {
+	res := &Response{States: []*State{
+		{Icao24: "8076c4", TimePosition: 1545462879, Latitude: 28.4392, Longitude: 77.4861},
+		{Icao24: "aa56da", TimePosition: 1545462879, Latitude: 30.1, Longitude: 77.4},
+		{Icao24: "7c6b2f", TimePosition: NoTimePosition},
+	}}
+
+	states := res.WithinBbox(&Bbox{Lamin: 28, Lomin: 77, Lamax: 29, Lomax: 78})
+	if len(states) != 1 || states[0].Icao24 != "8076c4" {
+		t.Errorf("got %v, want only 8076c4", states)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	start, end := (&Response{Time: 1545462880}).Interval()
+	if !start.Equal(time.Unix(1545462879, 0)) || !end.Equal(time.Unix(1545462880, 0)) {
+		t.Errorf("got [%v, %v]", start, end)
+	}
+}
+
+func TestServerSkew(t *testing.T) {
+	now := time.Unix(1545462900, 0)
+	if got := (&Response{Time: 1545462880}).ServerSkew(now); got != -20*time.Second {
+		t.Errorf("got %v from the response time, want -20s", got)
+	}
+
+	res := &Response{Time: 1545462880, Headers: http.Header{"Date": {"Sat, 22 Dec 2018 07:15:05 GMT"}}}
+	if got := res.ServerSkew(now); got != 5*time.Second {
+		t.Errorf("got %v from the Date header, want 5s", got)
+	}
+}
+
+func TestStats(t *testing.T) {
+	res := &Response{States: []*State{
+		{OriginCountry: "India", TimePosition: 1545462879},
+		{OriginCountry: "India", OnGround: true, TimePosition: 1545462879, PositionSource: 2},
+		{OriginCountry: "Australia", TimePosition: NoTimePosition},
+	}}
+
+	stats := res.Stats()
+	if stats.Total != 3 || stats.Airborne != 2 || stats.OnGround != 1 || stats.NoPosition != 1 {
+		t.Errorf("got %+v", stats)
+	}
+	if stats.BySource[0] != 2 || stats.BySource[2] != 1 {
+		t.Errorf("got counts by source %v", stats.BySource)
+	}
+	if stats.ByCountry["India"] != 2 || stats.ByCountry["Australia"] != 1 {
+		t.Errorf("got counts by country %v", stats.ByCountry)
+	}
+}
+
+func TestGroupByCountry(t *testing.T) {
+	res := &Response{States: []*State{{Icao24: "8076c4", OriginCountry: "India"}, {Icao24: "7c6b2f", OriginCountry: "Australia"}, {Icao24: "800123", OriginCountry: "India"}, {Icao24: "000001"}}}
+
+	groups := res.GroupByCountry()
+	if len(groups) != 3 || len(groups["India"]) != 2 || len(groups["Australia"]) != 1 || len(groups[""]) != 1 {
+		t.Errorf("got %v", groups)
+	}
+}
+
+func TestCountryLeaderboard(t *testing.T) {
+	res := &Response{States: []*State{{OriginCountry: "India"}, {OriginCountry: "Germany"}, {OriginCountry: "India"}, {OriginCountry: "Australia"}}}
+
+	want := []CountryCount{{"India", 2}, {"Australia", 1}, {"Germany", 1}}
+	if got := res.CountryLeaderboard(); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDropBogus(t *testing.T) {
+	res := &Response{States: []*State{
+		{Icao24: "ok", TimePosition: 1545462879, Latitude: 28.4392, Longitude: 77.4861, BaroAltitude: 1744.98, Velocity: 110.61},
+		{Icao24: "no position", TimePosition: NoTimePosition},
+		{Icao24: "null island", TimePosition: 1545462879},
+		{Icao24: "underground", TimePosition: 1545462879, Latitude: 1, BaroAltitude: -9999},
+		{Icao24: "too high", TimePosition: 1545462879, Latitude: 1, GeoAltitude: 30000},
+		{Icao24: "too fast", TimePosition: 1545462879, Latitude: 1, Velocity: 900},
+	}}
+
+	kept, dropped := res.DropBogus()
+	if dropped != 4 || len(kept) != 2 || kept[0].Icao24 != "ok" || kept[1].Icao24 != "no position" {
+		t.Errorf("got %d dropped, kept %v", dropped, kept)
+	}
+
+	limits := DefaultSanityLimits
+	limits.AllowNullIsland = true
+	if _, dropped := res.DropBogusWith(limits); dropped != 3 {
+		t.Errorf("got %d dropped, want 3 when allowing 0,0", dropped)
+	}
+}
+
+func TestPages(t *testing.T) {
+	res := &Response{States: []*State{{Icao24: "1"}, {Icao24: "2"}, {Icao24: "3"}, {Icao24: "4"}, {Icao24: "5"}}}
+
+	var sizes []int
+	for page := range res.Pages(2) {
+		sizes = append(sizes, len(page))
+	}
+	if len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Errorf("got page sizes %v, want [2 2 1]", sizes)
+	}
+}
+
+func TestMergeSensors(t *testing.T) {
+	first := &Response{States: []*State{{Icao24: "8076c4", Sensors: []int{7, 3}}, {Icao24: "aa56da", Sensors: []int{}}}}
+	second := &Response{States: []*State{{Icao24: "8076c4", Sensors: []int{3, 12}}}}
+
+	merged := MergeSensors(first, second)
+	if len(merged) != 1 || fmt.Sprint(merged["8076c4"]) != "[3 7 12]" {
+		t.Errorf("got %v, want map[8076c4:[3 7 12]]", merged)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := int(time.Now().Unix())
+	res := &Response{Time: now, States: []*State{{Icao24: "8076c4", PositionSource: 2}}}
+	if err := res.Validate(); err != nil {
+		t.Errorf("got %v for a valid response", err)
+	}
+
+	res = &Response{Time: now + 3600, States: []*State{{Icao24: "8076c4"}, {PositionSource: 7}}}
+	err := res.Validate()
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 3 {
+		t.Errorf("got %q, want three violations", errs)
+	}
+	if err := (&Response{}).Validate(); err == nil {
+		t.Error("want an error for time 0")
+	}
+}
+
+func TestAltitudeHistogram(t *testing.T) {
+	res := &Response{States: []*State{
+		{BaroAltitude: 10668, GeoAltitude: 10900},
+		{BaroAltitude: 10972.8},
+		{BaroAltitude: 1744.98, GeoAltitude: 1767.84},
+		{OnGround: true, BaroAltitude: 120},
+		{},
+	}}
+
+	if got := res.AltitudeHistogram(1000); len(got) != 2 || got[10] != 2 || got[1] != 1 {
+		t.Errorf("got %v, want 2 in band 10 and 1 in band 1", got)
+	}
+	// 10668 m is FL350, 10972.8 m FL360 and 1744.98 m FL57.
+	if got := res.FlightLevelHistogram(10); len(got) != 3 || got[35] != 1 || got[36] != 1 || got[5] != 1 {
+		t.Errorf("got %v, want one aircraft each in bands 35, 36 and 5", got)
+	}
+
+	if got := res.AltitudeHistogram(0); len(got) != 0 {
+		t.Errorf("got %v for bands of 0 m, want an empty histogram", got)
+	}
+	if got := res.FlightLevelHistogram(-10); len(got) != 0 {
+		t.Errorf("got %v for bands of -10 levels, want an empty histogram", got)
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	mlat := &State{Icao24: "8076c4", TimePosition: 1545462889, LastContact: 1545462889, PositionSource: SourceMLAT}
+	adsb := &State{Icao24: "8076c4", TimePosition: 1545462879, LastContact: 1545462879, PositionSource: SourceADSB}
+	noPosition := &State{Icao24: "8076c4", TimePosition: NoTimePosition, LastContact: 1545462890}
+	other := &State{Icao24: "aa56da", TimePosition: NoTimePosition}
+	res := &Response{States: []*State{mlat, other, noPosition, adsb}}
+
+	if got := res.Dedupe(nil); len(got) != 2 || got[0] != adsb || got[1] != other {
+		t.Errorf("got %v, want ADS-B preferred", got)
+	}
+	if got := res.Dedupe([]PositionSource{SourceMLAT}); got[0] != mlat {
+		t.Errorf("got %v, want MLAT preferred", got[0])
+	}
+}
+
+func TestByICAO24(t *testing.T) {
+	mlat := &State{Icao24: "8076c4", TimePosition: 1545462889, PositionSource: SourceMLAT}
+	adsb := &State{Icao24: "8076c4", TimePosition: 1545462879, PositionSource: SourceADSB}
+	res := &Response{States: []*State{mlat, adsb, {Icao24: "aa56da"}}}
+
+	if got := res.ByICAO24(); len(got) != 2 || got["8076c4"] != adsb || got["aa56da"] == nil {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestCallsignIndex(t *testing.T) {
+	res := &Response{States: []*State{
+		{Icao24: "3c6586", Callsign: "DLH400  ", LastContact: 1545462870},
+		{Icao24: "3c6444", Callsign: "DLH400  ", LastContact: 1545462879},
+		{Icao24: "8076c4", Callsign: "JAI824  "},
+		{Icao24: "aa56da", Callsign: "        "},
+	}}
+
+	index := res.CallsignIndex()
+	if len(index) != 2 || index["DLH400"] != "3c6444" || index["JAI824"] != "8076c4" {
+		t.Errorf("got %v", index)
+	}
+}
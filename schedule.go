@@ -0,0 +1,43 @@
+package gopensky
+
+import (
+	"strconv"
+	"time"
+)
+
+// Header in which OpenSky reports the API credits left for the day.
+const RemainingCreditsHeader = "X-Rate-Limit-Remaining"
+
+// RemainingCredits returns the API credits left for the day as reported with the response. It is only available for
+// responses fetched with WithCaptureHeaders.
+func (r *Response) RemainingCredits() (int, bool) {
+	if r.Headers == nil {
+		return 0, false
+	}
+	remaining, err := strconv.Atoi(r.Headers.Get(RemainingCreditsHeader))
+	if err != nil {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// AdaptiveInterval returns how long to wait before polling again so that the remaining credits last until they are
+// reset at midnight UTC, given that each poll costs as much as the one that returned res. It never returns less than
+// minInterval, and returns minInterval if res does not report the remaining credits.
+func AdaptiveInterval(res *Response, minInterval time.Duration) time.Duration {
+	remaining, ok := res.RemainingCredits()
+	if !ok {
+		return minInterval
+	}
+	return adaptiveInterval(remaining, res.CreditsUsed, minInterval, time.Now())
+}
+
+func adaptiveInterval(remaining, cost int, minInterval time.Duration, now time.Time) time.Duration {
+	now = now.UTC()
+	untilReset := now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+	polls := remaining / max(cost, 1)
+	if polls < 1 {
+		return max(untilReset, minInterval)
+	}
+	return max(untilReset/time.Duration(polls), minInterval)
+}
@@ -0,0 +1,36 @@
+package gopensky
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveInterval(t *testing.T) {
+	noon := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		remaining, cost int
+		want            time.Duration
+	}{
+		{1200, 1, 36 * time.Second},
+		{1200, 4, 144 * time.Second},
+		{100000, 1, 10 * time.Second},
+		{3, 4, 12 * time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := adaptiveInterval(test.remaining, test.cost, 10*time.Second, noon); got != test.want {
+			t.Errorf("%d credits left at %d per poll: got %v, want %v", test.remaining, test.cost, got, test.want)
+		}
+	}
+}
+
+func TestRemainingCredits(t *testing.T) {
+	res := &Response{Headers: http.Header{"X-Rate-Limit-Remaining": {"3996"}}}
+	if remaining, ok := res.RemainingCredits(); !ok || remaining != 3996 {
+		t.Errorf("got %d, %t", remaining, ok)
+	}
+	if AdaptiveInterval(&Response{}, time.Minute) != time.Minute {
+		t.Error("want the minimum interval without captured headers")
+	}
+}
@@ -0,0 +1,137 @@
+package gopensky
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Difference between geometric and barometric altitude of simulated aircraft in meters.
+const simulatedAltitudeOffset = 100
+
+type simulator struct {
+	bbox Bbox
+	now  func() time.Time
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	aircraft []*State
+	last     time.Time
+}
+
+// NewSimulator returns an Api that serves states of numAircraft simulated aircraft within bbox, or anywhere if bbox is
+// nil, without any network access. Between calls the aircraft move according to their velocity, track and vertical
+// rate, and turn around at the edges of the box. The simulation is deterministic for a given number of aircraft and
+// box.
+func NewSimulator(numAircraft int, bbox *Bbox) Api {
+	s := &simulator{
+		bbox: Bbox{Lamin: -85, Lomin: -180, Lamax: 85, Lomax: 180},
+		now:  time.Now,
+		rng:  rand.New(rand.NewSource(int64(numAircraft))),
+	}
+	if bbox != nil {
+		s.bbox = *bbox
+	}
+
+	for i := 0; i < numAircraft; i++ {
+		s.aircraft = append(s.aircraft, s.newAircraft(i))
+	}
+	return s
+}
+
+func (s *simulator) newAircraft(i int) *State {
+	b := s.bbox
+	a := &State{
+		Icao24:         fmt.Sprintf("%06x", 0xf00000+i),
+		Callsign:       fmt.Sprintf("SIM%04d ", i),
+		OriginCountry:  "Simulation",
+		Latitude:       b.Lamin + s.rng.Float64()*(b.Lamax-b.Lamin),
+		Longitude:      b.Lomin + s.rng.Float64()*(b.Lomax-b.Lomin),
+		BaroAltitude:   1000 + s.rng.Float64()*11000,
+		Velocity:       60 + s.rng.Float64()*190,
+		TrueTrack:      s.rng.Float64() * 360,
+		VerticalRate:   float64(s.rng.Intn(3)-1) * 5,
+		Sensors:        []int{},
+		Squawk:         fmt.Sprintf("%04o", s.rng.Intn(010000)),
+		PositionSource: SourceADSB,
+	}
+	a.GeoAltitude = a.BaroAltitude + simulatedAltitudeOffset
+	return a
+}
+
+func (s *simulator) Get(req *Request) (*Response, error) {
+	return s.GetContext(context.Background(), req)
+}
+
+func (s *simulator) GetContext(ctx context.Context, req *Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if req != nil && req.Bbox != nil {
+		if err := req.Bbox.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !s.last.IsZero() {
+		s.advance(now.Sub(s.last))
+	}
+	s.last = now
+
+	res := &Response{Time: int(now.Unix()), States: make([]*State, 0, len(s.aircraft))}
+	for _, a := range s.aircraft {
+		if !matches(req, a) {
+			continue
+		}
+		state := *a
+		state.Sensors = []int{}
+		state.TimePosition = res.Time
+		state.LastContact = res.Time
+		res.States = append(res.States, &state)
+	}
+	return res, nil
+}
+
+func (s *simulator) advance(elapsed time.Duration) {
+	for _, a := range s.aircraft {
+		p := destination(Point{Latitude: a.Latitude, Longitude: a.Longitude}, a.TrueTrack, a.Velocity*elapsed.Seconds()/1000)
+		if !s.bbox.Contains(p.Latitude, p.Longitude) {
+			// Turn around rather than leave the box.
+			a.TrueTrack = math.Mod(a.TrueTrack+180, 360)
+			continue
+		}
+		a.Latitude, a.Longitude = p.Latitude, p.Longitude
+
+		a.BaroAltitude += a.VerticalRate * elapsed.Seconds()
+		if a.BaroAltitude < 1000 || a.BaroAltitude > 12000 {
+			a.VerticalRate = -a.VerticalRate
+		}
+		a.GeoAltitude = a.BaroAltitude + simulatedAltitudeOffset
+	}
+}
+
+// matches reports whether the state satisfies the filters of the request.
+func matches(req *Request, s *State) bool {
+	if req == nil {
+		return true
+	}
+	if req.Bbox != nil && !req.Bbox.Contains(s.Latitude, s.Longitude) {
+		return false
+	}
+	if len(req.Icao24) == 0 {
+		return true
+	}
+	for _, icao24 := range req.Icao24 {
+		if icao24 == s.Icao24 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package gopensky
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulator(t *testing.T) {
+	bbox := &Bbox{Lamin: 45.8389, Lomin: 5.9962, Lamax: 47.8229, Lomax: 10.5226}
+	api := NewSimulator(20, bbox)
+	now := time.Unix(1545462880, 0)
+	api.(*simulator).now = func() time.Time { return now }
+
+	first, err := api.Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.States) != 20 {
+		t.Fatalf("got %d states, want 20", len(first.States))
+	}
+
+	now = now.Add(10 * time.Second)
+	second, err := api.Get(&Request{Icao24: []string{first.States[0].Icao24}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.States) != 1 {
+		t.Fatalf("got %d states, want the filtered aircraft only", len(second.States))
+	}
+
+	a, b := first.States[0], second.States[0]
+	if a.Latitude == b.Latitude && a.Longitude == b.Longitude {
+		t.Error("aircraft did not move")
+	}
+	if !bbox.Contains(b.Latitude, b.Longitude) {
+		t.Errorf("aircraft left the box: %f, %f", b.Latitude, b.Longitude)
+	}
+	if second.Time != first.Time+10 || b.LastContact != second.Time {
+		t.Errorf("got times %d and %d, want 10s apart", first.Time, second.Time)
+	}
+}
@@ -0,0 +1,108 @@
+package gopensky
+
+import "time"
+
+// NoTimePosition is the TimePosition of a state without a position.
+const NoTimePosition = -1
+
+// HasPosition reports whether the state carries a position. Longitude and Latitude are zero otherwise.
+func (s *State) HasPosition() bool {
+	return s.TimePosition > 0
+}
+
+// Phase is the phase of flight derived from a state vector.
+type Phase int
+
+const (
+	PhaseUnknown Phase = iota
+	PhaseGround
+	PhaseClimb
+	PhaseDescent
+	PhaseCruise
+)
+
+const (
+	// Vertical rates within this many m/s of zero count as level flight.
+	levelFlightRate = 2.5
+	// Level flight below this barometric altitude in meters is not considered cruise.
+	cruiseAltitude = 3000
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseGround:
+		return "Ground"
+	case PhaseClimb:
+		return "Climb"
+	case PhaseDescent:
+		return "Descent"
+	case PhaseCruise:
+		return "Cruise"
+	default:
+		return "Unknown"
+	}
+}
+
+// FlightPhase classifies the state by its ground flag, vertical rate and barometric altitude. Level flight at low
+// altitude, e.g. in a traffic pattern or holding, is reported as PhaseUnknown.
+func (s *State) FlightPhase() Phase {
+	switch {
+	case s.OnGround:
+		return PhaseGround
+	case s.VerticalRate > levelFlightRate:
+		return PhaseClimb
+	case s.VerticalRate < -levelFlightRate:
+		return PhaseDescent
+	case s.BaroAltitude >= cruiseAltitude:
+		return PhaseCruise
+	default:
+		return PhaseUnknown
+	}
+}
+
+// VerticalTrend labels the vertical rate as "climbing", "descending" or, within deadBand m/s of zero, "level". A null
+// vertical rate decodes as 0 and cannot be told apart from level flight, so vehicles on the ground, which report none,
+// are labelled "unknown".
+func (s *State) VerticalTrend(deadBand float64) string {
+	switch {
+	case s.OnGround:
+		return "unknown"
+	case s.VerticalRate > deadBand:
+		return "climbing"
+	case s.VerticalRate < -deadBand:
+		return "descending"
+	default:
+		return "level"
+	}
+}
+
+// BestAltitude returns the geometric altitude in meters if the state has one and the barometric altitude otherwise,
+// along with "geometric" or "barometric" as the source. A null altitude decodes as 0, so an altitude of exactly 0
+// counts as missing, and ok is false if both are.
+func (s *State) BestAltitude() (meters float64, source string, ok bool) {
+	switch {
+	case s.GeoAltitude != 0:
+		return s.GeoAltitude, "geometric", true
+	case s.BaroAltitude != 0:
+		return s.BaroAltitude, "barometric", true
+	default:
+		return 0, "", false
+	}
+}
+
+// ReachableArea returns a ring of the given number of points around the current position, at the distance the vehicle
+// covers within horizon at its current velocity. It returns nil if the state has no position or no velocity.
+func (s *State) ReachableArea(horizon time.Duration, points int) []Point {
+	if !s.HasPosition() || s.Velocity <= 0 || points <= 0 {
+		return nil
+	}
+
+	center := Point{Latitude: s.Latitude, Longitude: s.Longitude}
+	distanceKm := s.Velocity * horizon.Seconds() / 1000
+
+	ring := make([]Point, points)
+	for i := range ring {
+		ring[i] = destination(center, 360*float64(i)/float64(points), distanceKm)
+	}
+	return ring
+}
@@ -0,0 +1,85 @@
+package gopensky
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFlightPhase(t *testing.T) {
+	tests := []struct {
+		state *State
+		want  Phase
+	}{
+		{&State{OnGround: true, VerticalRate: 5}, PhaseGround},
+		{&State{BaroAltitude: 1000, VerticalRate: 8}, PhaseClimb},
+		{&State{BaroAltitude: 9000, VerticalRate: -6}, PhaseDescent},
+		{&State{BaroAltitude: 11000, VerticalRate: 0.3}, PhaseCruise},
+		{&State{BaroAltitude: 600}, PhaseUnknown},
+	}
+
+	for _, test := range tests {
+		if got := test.state.FlightPhase(); got != test.want {
+			t.Errorf("%+v: got %v, want %v", test.state, got, test.want)
+		}
+	}
+}
+
+func TestVerticalTrend(t *testing.T) {
+	tests := []struct {
+		state *State
+		want  string
+	}{
+		{&State{VerticalRate: 3.2}, "climbing"},
+		{&State{VerticalRate: -3.2}, "descending"},
+		{&State{VerticalRate: 0.8}, "level"},
+		{&State{VerticalRate: -1}, "level"},
+		{&State{OnGround: true}, "unknown"},
+	}
+
+	for _, test := range tests {
+		if got := test.state.VerticalTrend(1); got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.state, got, test.want)
+		}
+	}
+}
+
+func TestBestAltitude(t *testing.T) {
+	tests := []struct {
+		state  *State
+		meters float64
+		source string
+	}{
+		{&State{BaroAltitude: 1744.98, GeoAltitude: 1767.84}, 1767.84, "geometric"},
+		{&State{BaroAltitude: 1744.98}, 1744.98, "barometric"},
+		{&State{}, 0, ""},
+	}
+
+	for _, test := range tests {
+		meters, source, ok := test.state.BestAltitude()
+		if meters != test.meters || source != test.source || ok != (test.source != "") {
+			t.Errorf("%+v: got %g, %q, %t", test.state, meters, source, ok)
+		}
+	}
+}
+
+func TestReachableArea(t *testing.T) {
+	s := &State{TimePosition: 1545462879, Latitude: 0, Longitude: 179.9, Velocity: 250}
+
+	ring := s.ReachableArea(10*time.Minute, 4)
+	if len(ring) != 4 {
+		t.Fatalf("got %d points, want 4", len(ring))
+	}
+
+	// 150 km is about 1.349 degrees at the equator.
+	if north := ring[0]; math.Abs(north.Latitude-1.349) > 0.001 || math.Abs(north.Longitude-179.9) > 0.001 {
+		t.Errorf("got northern point %+v", north)
+	}
+	if east := ring[1]; math.Abs(east.Longitude+178.751) > 0.001 {
+		t.Errorf("got eastern point %+v, want it wrapped across the antimeridian", east)
+	}
+
+	if ring := (&State{TimePosition: NoTimePosition, Velocity: 250}).ReachableArea(time.Minute, 4); ring != nil {
+		t.Errorf("got %v for a state without position, want nil", ring)
+	}
+}
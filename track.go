@@ -0,0 +1,148 @@
+package gopensky
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Track is the trajectory of an aircraft, in the shape of the result of OpenSky's /tracks/all endpoint.
+type Track struct {
+	Icao24 string
+	// Times of the first and last waypoint.
+	StartTime int
+	EndTime   int
+	Callsign  string
+	// Waypoints in order of time.
+	Path []Waypoint
+}
+
+// Waypoint is a position on a track.
+type Waypoint struct {
+	Time         int
+	Latitude     float64
+	Longitude    float64
+	BaroAltitude float64
+	TrueTrack    float64
+	OnGround     bool
+}
+
+// TrackAccumulator builds tracks from the positions in successive responses, with the time resolution of the polling
+// rather than that of /tracks/all. The zero value is ready to use.
+type TrackAccumulator struct {
+	// Ground speed in m/s above which a position is implausible, e.g. 400, and taken for a jump caused by a bad fix such
+	// as from MLAT. Zero means any speed is plausible.
+	MaxSpeed float64
+	// OnJump is called for every position left off a track as a jump, with the waypoint it was compared to and the
+	// speed implied between the two. It is called after Add updated the tracks, so it may use the accumulator.
+	OnJump func(s *State, from Waypoint, speed float64)
+
+	mu     sync.Mutex
+	tracks map[string]*accumulatedTrack
+}
+
+// jump is a position rejected by Add, to be reported with OnJump.
+type jump struct {
+	s     *State
+	from  Waypoint
+	speed float64
+}
+
+type accumulatedTrack struct {
+	callsign string
+	path     []Waypoint
+}
+
+// Add appends the positions in resp to the tracks of their aircraft. States without a position and positions already
+// on the track, such as those repeated by consecutive responses, are skipped. With MaxSpeed, positions that would
+// imply a higher speed from the waypoint before them, or after them for the first position of a track, are skipped
+// and reported to OnJump.
+func (a *TrackAccumulator) Add(resp *Response) {
+	jumps := a.add(resp)
+	if a.OnJump != nil {
+		for _, j := range jumps {
+			a.OnJump(j.s, j.from, j.speed)
+		}
+	}
+}
+
+func (a *TrackAccumulator) add(resp *Response) []jump {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var jumps []jump
+	if a.tracks == nil {
+		a.tracks = make(map[string]*accumulatedTrack)
+	}
+	for _, s := range resp.States {
+		if !s.HasPosition() {
+			continue
+		}
+
+		icao24 := strings.ToLower(s.Icao24)
+		t := a.tracks[icao24]
+		if t == nil {
+			t = &accumulatedTrack{}
+			a.tracks[icao24] = t
+		}
+		if callsign := trimCallsign(s.Callsign); callsign != "" {
+			t.callsign = callsign
+		}
+
+		i, found := slices.BinarySearchFunc(t.path, s.TimePosition, func(w Waypoint, time int) int {
+			return w.Time - time
+		})
+		if found {
+			continue
+		}
+		if j, ok := a.jump(t.path, i, s); ok {
+			jumps = append(jumps, j)
+			continue
+		}
+		t.path = slices.Insert(t.path, i, Waypoint{
+			Time:         s.TimePosition,
+			Latitude:     s.Latitude,
+			Longitude:    s.Longitude,
+			BaroAltitude: s.BaroAltitude,
+			TrueTrack:    s.TrueTrack,
+			OnGround:     s.OnGround,
+		})
+	}
+	return jumps
+}
+
+// jump checks whether inserting the position of s at index i of path implies a speed above MaxSpeed.
+func (a *TrackAccumulator) jump(path []Waypoint, i int, s *State) (jump, bool) {
+	if a.MaxSpeed <= 0 || len(path) == 0 {
+		return jump{}, false
+	}
+
+	from := path[max(i-1, 0)]
+	seconds := math.Abs(float64(s.TimePosition - from.Time))
+	prev, next := Point{Latitude: from.Latitude, Longitude: from.Longitude}, Point{Latitude: s.Latitude, Longitude: s.Longitude}
+	meters := distanceKm(prev, next) * 1000
+	if speed := meters / seconds; speed > a.MaxSpeed {
+		return jump{s: s, from: from, speed: speed}, true
+	}
+	return jump{}, false
+}
+
+// Track returns the track accumulated for the aircraft with the given ICAO24 address, or nil if none of its positions
+// were added.
+func (a *TrackAccumulator) Track(icao24 string) *Track {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	t := a.tracks[strings.ToLower(icao24)]
+	if t == nil {
+		return nil
+	}
+	return &Track{
+		Icao24:    strings.ToLower(icao24),
+		StartTime: t.path[0].Time,
+		EndTime:   t.path[len(t.path)-1].Time,
+		Callsign:  t.callsign,
+		Path:      slices.Clone(t.path),
+	}
+}
@@ -0,0 +1,56 @@
+package gopensky
+
+import "testing"
+
+func TestTrackAccumulator(t *testing.T) {
+	var acc TrackAccumulator
+	acc.Add(&Response{States: []*State{
+		{Icao24: "8076c4", Callsign: "AWQ9519 ", TimePosition: 1545462889, Latitude: -6.12, Longitude: 106.81, BaroAltitude: 1440},
+		{Icao24: "aa56da", TimePosition: NoTimePosition},
+	}})
+	acc.Add(&Response{States: []*State{
+		{Icao24: "8076c4", TimePosition: 1545462889, Latitude: -6.12, Longitude: 106.81, BaroAltitude: 1440},
+	}})
+	acc.Add(&Response{States: []*State{
+		{Icao24: "8076C4", TimePosition: 1545462879, Latitude: -6.11, Longitude: 106.80, BaroAltitude: 1400},
+	}})
+
+	track := acc.Track("8076C4")
+	if track == nil || len(track.Path) != 2 {
+		t.Fatalf("got track %+v, want two waypoints", track)
+	}
+	if track.StartTime != 1545462879 || track.EndTime != 1545462889 || track.Path[0].BaroAltitude != 1400 {
+		t.Errorf("got track %+v, want the waypoints in order of time", track)
+	}
+	if track.Icao24 != "8076c4" || track.Callsign != "AWQ9519" {
+		t.Errorf("got icao24 %q and callsign %q", track.Icao24, track.Callsign)
+	}
+	if acc.Track("aa56da") != nil {
+		t.Error("want no track for an aircraft without positions")
+	}
+}
+
+func TestTrackAccumulatorJumps(t *testing.T) {
+	var jumps []*State
+	acc := TrackAccumulator{MaxSpeed: 400, OnJump: func(s *State, from Waypoint, speed float64) {
+		if from.Time != 1545462879 || speed < 400 {
+			t.Errorf("got a jump from %+v at %g m/s", from, speed)
+		}
+		jumps = append(jumps, s)
+	}}
+
+	start := &State{Icao24: "8076c4", TimePosition: 1545462879, Latitude: 28.4392, Longitude: 77.4861}
+	// About 2.2 km in 10 s is 220 m/s.
+	plausible := &State{Icao24: "8076c4", TimePosition: 1545462889, Latitude: 28.4592, Longitude: 77.4861}
+	// About 55 km in 5 s.
+	jump := &State{Icao24: "8076c4", TimePosition: 1545462884, Latitude: 28.9392, Longitude: 77.4861}
+	acc.Add(&Response{States: []*State{start}})
+	acc.Add(&Response{States: []*State{plausible, jump}})
+
+	if len(jumps) != 1 || jumps[0] != jump {
+		t.Errorf("got jumps %v, want only the implausible position", jumps)
+	}
+	if track := acc.Track("8076c4"); len(track.Path) != 2 || track.EndTime != 1545462889 {
+		t.Errorf("got track %+v, want the jump left off", track)
+	}
+}
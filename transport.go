@@ -0,0 +1,53 @@
+package gopensky
+
+import (
+	"net/http"
+	"time"
+)
+
+// TransportTuning are the connection pool settings of the HTTP transport used by an Api.
+type TransportTuning struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+}
+
+// DefaultTransportTuning keeps enough idle connections to OpenSky for frequent polling from several goroutines. The
+// net/http default of 2 idle connections per host causes connection churn when all requests go to a single host.
+var DefaultTransportTuning = TransportTuning{
+	MaxIdleConns:        32,
+	MaxIdleConnsPerHost: 16,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// NewDefault returns an Api with its own HTTP client, tuned with DefaultTransportTuning.
+func NewDefault(opts ...Option) Api {
+	return New(&http.Client{}, append([]Option{WithTransportTuning(DefaultTransportTuning)}, opts...)...)
+}
+
+// WithTransportTuning applies the connection pool settings to the transport of the Api's HTTP client and enables
+// HTTP/2. The client passed to New is left untouched: the Api uses a copy with a tuned copy of its transport. Clients
+// with a custom http.RoundTripper are used unchanged.
+func WithTransportTuning(tuning TransportTuning) Option {
+	return func(a *api) {
+		var base *http.Transport
+		switch t := a.Http.Transport.(type) {
+		case nil:
+			base = http.DefaultTransport.(*http.Transport)
+		case *http.Transport:
+			base = t
+		default:
+			return
+		}
+
+		transport := base.Clone()
+		transport.MaxIdleConns = tuning.MaxIdleConns
+		transport.MaxIdleConnsPerHost = tuning.MaxIdleConnsPerHost
+		transport.IdleConnTimeout = tuning.IdleConnTimeout
+		transport.ForceAttemptHTTP2 = true
+
+		client := *a.Http
+		client.Transport = transport
+		a.Http = &client
+	}
+}
@@ -0,0 +1,29 @@
+package gopensky
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewDefault(t *testing.T) {
+	transport, ok := NewDefault().(*api).Http.Transport.(*http.Transport)
+	if !ok {
+		t.Fatal("want an *http.Transport")
+	}
+	if transport.MaxIdleConnsPerHost != DefaultTransportTuning.MaxIdleConnsPerHost || !transport.ForceAttemptHTTP2 {
+		t.Errorf("got MaxIdleConnsPerHost %d and ForceAttemptHTTP2 %t", transport.MaxIdleConnsPerHost, transport.ForceAttemptHTTP2)
+	}
+}
+
+func TestWithTransportTuningCopiesClient(t *testing.T) {
+	client := &http.Client{Timeout: time.Second}
+	a := New(client, WithTransportTuning(TransportTuning{MaxIdleConnsPerHost: 4})).(*api)
+
+	if client.Transport != nil {
+		t.Error("the client passed to New was modified")
+	}
+	if a.Http.Timeout != time.Second || a.Http.Transport.(*http.Transport).MaxIdleConnsPerHost != 4 {
+		t.Errorf("got client %+v, want a tuned copy", a.Http)
+	}
+}